@@ -14,6 +14,7 @@
 package commands
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
@@ -40,6 +41,10 @@ Note that Hugo will always start out by resolving the components defined in the
 configuration, provided by a _vendor directory (if no --ignoreVendor flag provided),
 Go Modules, or a folder inside the themes directory, in that order.
 
+If the verifyVendor config setting is enabled, Hugo will fail fast on startup if any
+module below _vendor has drifted from the checksum recorded for it in modules.txt;
+see "hugo mod verify" to check this without building the site.
+
 `
 
 	cmd := &cobra.Command{
@@ -83,6 +88,15 @@ Run "go help get" for more information.
 				})
 			},
 		},
+		&cobra.Command{
+			Use:   "outdated",
+			Short: "List modules with an available update or a deprecation notice.",
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return c.withModsClient(func(c *modules.Client) error {
+					return c.Outdated(os.Stdout)
+				})
+			},
+		},
 		&cobra.Command{
 			Use:   "init",
 			Short: "TODO(bep) ",
@@ -114,6 +128,36 @@ Run "go help get" for more information.
 				})
 			},
 		},
+		&cobra.Command{
+			Use:   "verify",
+			Short: "Verify that the _vendor directory matches the recorded checksums.",
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return c.withModsClient(func(c *modules.Client) error {
+					return c.Verify()
+				})
+			},
+		},
+		&cobra.Command{
+			Use:   "why [module]",
+			Short: "Explain why a given module is needed.",
+			Args:  cobra.ExactArgs(1),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return c.withModsClient(func(c *modules.Client) error {
+					chain, err := c.Why(args[0])
+					if err != nil {
+						return err
+					}
+					if len(chain) == 0 {
+						fmt.Println("(module not used)")
+						return nil
+					}
+					for _, p := range chain {
+						fmt.Println(p)
+					}
+					return nil
+				})
+			},
+		},
 	)
 
 	c.baseBuilderCmd = b.newBuilderCmd(cmd)
@@ -149,6 +193,7 @@ func (c *modCmd) newModsClient(cfg config.Provider) (*modules.Client, error) {
 		modProxy     string
 		modConfig    modules.Config
 		ignoreVendor bool
+		verifyVendor bool
 	)
 
 	if c.source != "" {
@@ -172,6 +217,7 @@ func (c *modCmd) newModsClient(cfg config.Provider) (*modules.Client, error) {
 			return nil, err
 		}
 		ignoreVendor = cfg.GetBool("ignoreVendor")
+		verifyVendor = cfg.GetBool("verifyVendor")
 		modProxy = cfg.GetString("modProxy")
 	}
 
@@ -181,6 +227,7 @@ func (c *modCmd) newModsClient(cfg config.Provider) (*modules.Client, error) {
 		ThemesDir:    themesDir,
 		ModuleConfig: modConfig,
 		IgnoreVendor: ignoreVendor,
+		Verify:       verifyVendor,
 		ModProxy:     modProxy,
 	}), nil
 
@@ -0,0 +1,60 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugofs
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+const hugoCacheDirEnvKey = "HUGO_CACHEDIR"
+
+// ModuleCacheDir returns the root directory used to cache remote module
+// content, honouring $HUGO_CACHEDIR if set.
+func ModuleCacheDir() string {
+	if d := os.Getenv(hugoCacheDirEnvKey); d != "" {
+		return filepath.Join(d, "modules", "filecache")
+	}
+	return filepath.Join(os.TempDir(), "hugo_cache", "modules", "filecache")
+}
+
+// NewCacheOnReadFs returns a new filesystem that caches every file read
+// from source into cache, analogous to afero's cacheOnReadFs. Once a file
+// has been read, further reads -- within maxAge -- are served from cache
+// without touching source at all. A maxAge of 0 caches forever, which is
+// appropriate for a specific, immutable module version: once resolved, it
+// will never change, so there is no reason to ever go back to source.
+//
+// This allows Hugo to cut cold-build I/O on large modules resolved from the
+// Go module proxy or a remote mount, and lets an offline build succeed
+// after any prior successful online build.
+func NewCacheOnReadFs(source, cache afero.Fs, maxAge time.Duration) afero.Fs {
+	return afero.NewCacheOnReadFs(source, cache, maxAge)
+}
+
+// NewModuleCacheFs returns a CacheOnReadFs rooted in a stable,
+// module-version-specific cache directory below ModuleCacheDir(). Keying the
+// cache dir on modulePath@version means two different versions of the same
+// module can never shadow each other's cached files.
+func NewModuleCacheFs(source afero.Fs, modulePath, version string, maxAge time.Duration) (fs afero.Fs, dir string, err error) {
+	dir = filepath.Join(ModuleCacheDir(), filepath.FromSlash(modulePath)+"@"+version)
+	if err = os.MkdirAll(dir, 0777); err != nil {
+		return nil, "", err
+	}
+	cache := afero.NewBasePathFs(afero.NewOsFs(), dir)
+	return NewCacheOnReadFs(source, cache, maxAge), dir, nil
+}
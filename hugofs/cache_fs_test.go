@@ -0,0 +1,51 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugofs
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewModuleCacheFs(t *testing.T) {
+	assert := require.New(t)
+
+	tmp, err := ioutil.TempDir("", "hugo-module-cache")
+	assert.NoError(err)
+	defer os.RemoveAll(tmp)
+	assert.NoError(os.Setenv(hugoCacheDirEnvKey, tmp))
+	defer os.Unsetenv(hugoCacheDirEnvKey)
+
+	source := afero.NewMemMapFs()
+	assert.NoError(afero.WriteFile(source, "README.md", []byte("hello"), 0755))
+
+	fs, dir, err := NewModuleCacheFs(source, "github.com/bep/mymodule", "v1.0.0", 0)
+	assert.NoError(err)
+	assert.Contains(dir, "github.com/bep/mymodule@v1.0.0")
+
+	f, err := fs.Open("README.md")
+	assert.NoError(err)
+	b, err := ioutil.ReadAll(f)
+	assert.NoError(err)
+	assert.Equal("hello", string(b))
+	f.Close()
+
+	cached, err := ioutil.ReadFile(dir + "/README.md")
+	assert.NoError(err)
+	assert.Equal("hello", string(cached))
+}
@@ -0,0 +1,246 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugofs
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"syscall"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+var (
+	_ afero.Fs      = (*FallbackFs)(nil)
+	_ afero.Lstater = (*FallbackFs)(nil)
+)
+
+// FallbackFs stacks a list of filesystems in priority order, the same
+// layering OverlayFs does. The difference is in what a lookup hands back:
+// every os.FileInfo FallbackFs returns is run through decorateFileInfo, so
+// it is a FileMetaInfo whose Meta().Filename() keeps the real, on-disk
+// filename set by the layer that supplied it (e.g. a BasePathRealFilenameFs
+// layer, one per module mount). A layer that sets no filename, such as a
+// plain afero.MemMapFs, leaves Meta().Filename() empty.
+type FallbackFs struct {
+	// Layers, highest priority (e.g. the project) first.
+	layers []afero.Fs
+}
+
+// NewFallbackFs creates a new FallbackFs stacking the given layers in the
+// order given, the first layer having the highest read priority. Create,
+// Mkdir, MkdirAll and OpenFile with a write flag go to layers[0] (NoOpFs if
+// there are no layers) and return whatever error that layer returns;
+// Remove, RemoveAll, Rename, Chmod and Chtimes always fail with
+// syscall.EROFS.
+func NewFallbackFs(layers ...afero.Fs) *FallbackFs {
+	return &FallbackFs{layers: layers}
+}
+
+func (ffs *FallbackFs) writable() afero.Fs {
+	if len(ffs.layers) == 0 {
+		return NoOpFs
+	}
+	return ffs.layers[0]
+}
+
+func (ffs *FallbackFs) getOpener(name string) func() (afero.File, error) {
+	return func() (afero.File, error) {
+		return ffs.Open(name)
+	}
+}
+
+func (ffs *FallbackFs) Chtimes(name string, atime, mtime time.Time) error {
+	return syscall.EROFS
+}
+
+func (ffs *FallbackFs) Chmod(name string, mode os.FileMode) error {
+	return syscall.EROFS
+}
+
+// Stat returns the first match in the stacked layers, decorated so its
+// RealFilename survives regardless of which layer supplied it.
+func (ffs *FallbackFs) Stat(name string) (os.FileInfo, error) {
+	for _, fs := range ffs.layers {
+		fi, err := fs.Stat(name)
+		if err == nil {
+			return decorateFileInfo(ffs, ffs.getOpener(name), fi, "", "", ""), nil
+		}
+		if !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+	return nil, &os.PathError{Op: "stat", Path: name, Err: os.ErrNotExist}
+}
+
+// LstatIfPossible returns the first match in the stacked layers, using
+// Lstat when the underlying layer supports it, decorated like Stat.
+func (ffs *FallbackFs) LstatIfPossible(name string) (os.FileInfo, bool, error) {
+	for _, fs := range ffs.layers {
+		var (
+			fi  os.FileInfo
+			b   bool
+			err error
+		)
+		if lst, ok := fs.(afero.Lstater); ok {
+			fi, b, err = lst.LstatIfPossible(name)
+		} else {
+			fi, err = fs.Stat(name)
+		}
+		if err == nil {
+			return decorateFileInfo(ffs, ffs.getOpener(name), fi, "", "", ""), b, nil
+		}
+		if !os.IsNotExist(err) {
+			return nil, false, err
+		}
+	}
+	return nil, false, &os.PathError{Op: "lstat", Path: name, Err: os.ErrNotExist}
+}
+
+// Open opens name for reading. If name is a directory present in more than
+// one layer, the returned afero.File's Readdir/Readdirnames union the
+// directory listings of every layer that has it, deduplicating by base
+// name so a higher-priority layer's entry shadows a lower one, with every
+// entry decorated the same way Stat decorates its result.
+func (ffs *FallbackFs) Open(name string) (afero.File, error) {
+	var files []afero.File
+
+	for _, fs := range ffs.layers {
+		f, err := fs.Open(name)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+		files = append(files, f)
+	}
+
+	if len(files) == 0 {
+		return nil, &os.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
+	}
+
+	fi, err := files[0].Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	if !fi.IsDir() || len(files) == 1 {
+		for _, f := range files[1:] {
+			f.Close()
+		}
+		return files[0], nil
+	}
+
+	return &fallbackDir{File: files[0], ffs: ffs, name: name, files: files}, nil
+}
+
+// OpenFile opens read-only requests through Open; any write flag sends the
+// call to the top layer, same as OverlayFs.
+func (ffs *FallbackFs) OpenFile(name string, flag int, perm os.FileMode) (afero.File, error) {
+	if flag&(os.O_WRONLY|os.O_RDWR|os.O_CREATE|os.O_APPEND|os.O_TRUNC) != 0 {
+		return ffs.writable().OpenFile(name, flag, perm)
+	}
+	return ffs.Open(name)
+}
+
+func (ffs *FallbackFs) Name() string {
+	return "FallbackFs"
+}
+
+func (ffs *FallbackFs) Create(name string) (afero.File, error) {
+	return ffs.writable().Create(name)
+}
+
+func (ffs *FallbackFs) Mkdir(name string, perm os.FileMode) error {
+	return ffs.writable().Mkdir(name, perm)
+}
+
+func (ffs *FallbackFs) MkdirAll(path string, perm os.FileMode) error {
+	return ffs.writable().MkdirAll(path, perm)
+}
+
+func (ffs *FallbackFs) Remove(name string) error {
+	return syscall.EROFS
+}
+
+func (ffs *FallbackFs) RemoveAll(path string) error {
+	return syscall.EROFS
+}
+
+func (ffs *FallbackFs) Rename(oldname, newname string) error {
+	return syscall.EROFS
+}
+
+// fallbackDir merges the directory listing of every layer that has name,
+// deduplicating entries by base name on Readdir/Readdirnames; every other
+// method is that of files[0], the highest-priority layer's open handle.
+type fallbackDir struct {
+	afero.File
+	ffs   *FallbackFs
+	name  string
+	files []afero.File
+}
+
+func (d *fallbackDir) Close() error {
+	var err error
+	for _, f := range d.files {
+		if cerr := f.Close(); cerr != nil {
+			err = cerr
+		}
+	}
+	return err
+}
+
+func (d *fallbackDir) Readdir(count int) ([]os.FileInfo, error) {
+	seen := make(map[string]bool)
+	var result []os.FileInfo
+
+	for _, f := range d.files {
+		entries, err := f.Readdir(-1)
+		if err != nil {
+			return nil, err
+		}
+		for _, fi := range entries {
+			if seen[fi.Name()] {
+				continue
+			}
+			seen[fi.Name()] = true
+			filename := filepath.Join(d.name, fi.Name())
+			result = append(result, decorateFileInfo(d.ffs, d.ffs.getOpener(filename), fi, "", "", ""))
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].Name() < result[j].Name() })
+
+	if count > 0 && count < len(result) {
+		result = result[:count]
+	}
+
+	return result, nil
+}
+
+func (d *fallbackDir) Readdirnames(count int) ([]string, error) {
+	fis, err := d.Readdir(count)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, len(fis))
+	for i, fi := range fis {
+		names[i] = fi.Name()
+	}
+	return names, nil
+}
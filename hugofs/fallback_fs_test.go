@@ -0,0 +1,149 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugofs
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+// A name present in more than one layer must resolve to the highest
+// priority layer that has it, falling through to the next when the
+// earlier ones don't.
+func TestFallbackFsOrdering(t *testing.T) {
+	assert := require.New(t)
+
+	project := afero.NewMemMapFs()
+	themeA := afero.NewMemMapFs()
+	themeB := afero.NewMemMapFs()
+
+	assert.NoError(afero.WriteFile(themeB, "assets/scss/_base.scss", []byte("base from B"), 0755))
+	assert.NoError(afero.WriteFile(themeA, "assets/scss/_vars.scss", []byte("vars from A"), 0755))
+
+	ffs := NewFallbackFs(project, themeA, themeB)
+
+	f, err := ffs.Open("assets/scss/_base.scss")
+	assert.NoError(err)
+	b, err := ioutil.ReadAll(f)
+	assert.NoError(err)
+	assert.Equal("base from B", string(b))
+	f.Close()
+
+	f, err = ffs.Open("assets/scss/_vars.scss")
+	assert.NoError(err)
+	b, err = ioutil.ReadAll(f)
+	assert.NoError(err)
+	assert.Equal("vars from A", string(b))
+	f.Close()
+
+	_, err = ffs.Open("assets/scss/does-not-exist.scss")
+	assert.Error(err)
+}
+
+// A higher-priority layer's file must shadow a lower-priority layer's file
+// of the same name.
+func TestFallbackFsShadowing(t *testing.T) {
+	assert := require.New(t)
+
+	project := afero.NewMemMapFs()
+	theme := afero.NewMemMapFs()
+
+	assert.NoError(afero.WriteFile(theme, "assets/scss/_base.scss", []byte("theme base"), 0755))
+	assert.NoError(afero.WriteFile(project, "assets/scss/_base.scss", []byte("project base"), 0755))
+
+	ffs := NewFallbackFs(project, theme)
+
+	fi, err := ffs.Stat("assets/scss/_base.scss")
+	assert.NoError(err)
+	assert.Equal(int64(len("project base")), fi.Size())
+
+	f, err := ffs.Open("assets/scss/_base.scss")
+	assert.NoError(err)
+	defer f.Close()
+	b, err := ioutil.ReadAll(f)
+	assert.NoError(err)
+	assert.Equal("project base", string(b))
+}
+
+// Readdir/Readdirnames on a directory present in several layers must union
+// their listings, deduplicated by base name with the higher-priority
+// layer's entry winning on a collision.
+func TestFallbackFsReaddirUnion(t *testing.T) {
+	assert := require.New(t)
+
+	project := afero.NewMemMapFs()
+	themeA := afero.NewMemMapFs()
+	themeB := afero.NewMemMapFs()
+
+	assert.NoError(afero.WriteFile(project, "assets/scss/_vars.scss", []byte("project vars"), 0755))
+	assert.NoError(afero.WriteFile(themeA, "assets/scss/_base.scss", []byte("A base"), 0755))
+	assert.NoError(afero.WriteFile(themeA, "assets/scss/_vars.scss", []byte("A vars, shadowed"), 0755))
+	assert.NoError(afero.WriteFile(themeB, "assets/scss/_mixins.scss", []byte("B mixins"), 0755))
+
+	ffs := NewFallbackFs(project, themeA, themeB)
+
+	d, err := ffs.Open("assets/scss")
+	assert.NoError(err)
+	defer d.Close()
+
+	names, err := d.Readdirnames(-1)
+	assert.NoError(err)
+	assert.ElementsMatch([]string{"_vars.scss", "_base.scss", "_mixins.scss"}, names)
+
+	fis, err := d.Readdir(-1)
+	assert.NoError(err)
+	for _, fi := range fis {
+		if fi.Name() == "_vars.scss" {
+			assert.Equal(int64(len("project vars")), fi.Size())
+		}
+	}
+}
+
+// Every os.FileInfo FallbackFs hands back, whether from Stat or a merged
+// Readdir, must still expose the real, on-disk filename of whichever layer
+// actually supplied it.
+func TestFallbackFsRealFilenamePreservation(t *testing.T) {
+	assert := require.New(t)
+
+	src := afero.NewMemMapFs()
+	assert.NoError(afero.WriteFile(src, "project/assets/scss/_vars.scss", []byte("project"), 0755))
+	assert.NoError(afero.WriteFile(src, "theme/assets/scss/_base.scss", []byte("theme"), 0755))
+
+	project := NewBasePathRealFilenameFs(afero.NewBasePathFs(src, "project").(*afero.BasePathFs))
+	theme := NewBasePathRealFilenameFs(afero.NewBasePathFs(src, "theme").(*afero.BasePathFs))
+
+	ffs := NewFallbackFs(project, theme)
+
+	fi, err := ffs.Stat("assets/scss/_vars.scss")
+	assert.NoError(err)
+	assert.Equal("project/assets/scss/_vars.scss", fi.(FileMetaInfo).Meta().Filename())
+
+	d, err := ffs.Open("assets/scss")
+	assert.NoError(err)
+	defer d.Close()
+
+	fis, err := d.Readdir(-1)
+	assert.NoError(err)
+	assert.Len(fis, 2)
+
+	filenames := make(map[string]string)
+	for _, fi := range fis {
+		filenames[fi.Name()] = fi.(FileMetaInfo).Meta().Filename()
+	}
+	assert.Equal("project/assets/scss/_vars.scss", filenames["_vars.scss"])
+	assert.Equal("theme/assets/scss/_base.scss", filenames["_base.scss"])
+}
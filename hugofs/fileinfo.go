@@ -15,6 +15,9 @@
 package hugofs
 
 import (
+	"crypto/sha1"
+	"encoding/hex"
+	"io"
 	"os"
 	"time"
 
@@ -33,6 +36,8 @@ const (
 	metaKeyLang     = "lang"
 	metaKeyFs       = "fs"
 	metaKeyOpener   = "opener"
+	metaKeyShadowed = "shadowed"
+	metaKeyHash     = "hash"
 )
 
 type FileMeta map[string]interface{}
@@ -77,6 +82,41 @@ func (f FileMeta) Path() string {
 	return f.stringV(metaKeyPath)
 }
 
+// Shadowed returns the real filenames of the mounts, if any, that this file
+// shadows in an overlaid RootMappingFs. See RootMappingFs.Conflicts.
+func (f FileMeta) Shadowed() []string {
+	if v, found := f[metaKeyShadowed]; found {
+		return v.([]string)
+	}
+	return nil
+}
+
+// Hash returns the SHA1 content hash of this file, streaming it through
+// Open() once and memoizing the result on this FileMeta so repeated calls
+// (e.g. across a rebuild) are free. It is meant for change detection, not
+// as a cryptographic digest.
+func (f FileMeta) Hash() (string, error) {
+	if v, found := f[metaKeyHash]; found {
+		return v.(string), nil
+	}
+
+	file, err := f.Open()
+	if err != nil {
+		return "", errors.Wrap(err, "failed to open file for hashing")
+	}
+	defer file.Close()
+
+	h := sha1.New()
+	if _, err := io.Copy(h, file); err != nil {
+		return "", errors.Wrap(err, "failed to hash file")
+	}
+
+	hash := hex.EncodeToString(h.Sum(nil))
+	f[metaKeyHash] = hash
+
+	return hash, nil
+}
+
 func (f FileMeta) Fs() afero.Fs {
 	if v, found := f[metaKeyFs]; found {
 		return v.(afero.Fs)
@@ -135,6 +175,7 @@ func mergeFileMeta(from, to FileMeta) {
 		}
 	}
 }
+
 type dirNameOnlyFileInfo struct {
 	name string
 }
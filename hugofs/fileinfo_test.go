@@ -0,0 +1,47 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugofs
+
+import (
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileMetaHash(t *testing.T) {
+	assert := require.New(t)
+
+	fs := afero.NewMemMapFs()
+	assert.NoError(afero.WriteFile(fs, "f.txt", []byte("some content"), 0755))
+
+	opens := 0
+	m := FileMeta{
+		metaKeyOpener: func() (afero.File, error) {
+			opens++
+			return fs.Open("f.txt")
+		},
+	}
+
+	hash, err := m.Hash()
+	assert.NoError(err)
+	assert.NotEmpty(hash)
+
+	// A second call should be served from the memoized value, not reopen
+	// the file.
+	hash2, err := m.Hash()
+	assert.NoError(err)
+	assert.Equal(hash, hash2)
+	assert.Equal(1, opens)
+}
@@ -0,0 +1,119 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package glob holds global Hugo glob filtering routines.
+package glob
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/gobwas/glob"
+	"github.com/pkg/errors"
+)
+
+// FilenameFilter is used to filter filenames in the mounted file systems,
+// typically to decide which files a module contributes to a given
+// component folder.
+type FilenameFilter struct {
+	shouldInclude func(filename string) bool
+	isDirInclude  func(filename string) bool
+}
+
+// NewFilenameFilter creates a new FilenameFilter from the given
+// include and exclude glob patterns (doublestar style, e.g. "**/*.md").
+// An empty FilenameFilter will match everything.
+func NewFilenameFilter(includes, excludes []string) (*FilenameFilter, error) {
+	if len(includes) == 0 && len(excludes) == 0 {
+		return nil, nil
+	}
+
+	var includeGlobs, excludeGlobs []glob.Glob
+
+	for _, include := range includes {
+		g, err := getGlob(include)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid include pattern %q", include)
+		}
+		includeGlobs = append(includeGlobs, g)
+	}
+
+	for _, exclude := range excludes {
+		g, err := getGlob(exclude)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid exclude pattern %q", exclude)
+		}
+		excludeGlobs = append(excludeGlobs, g)
+	}
+
+	f := &FilenameFilter{}
+
+	f.shouldInclude = func(filename string) bool {
+		filename = normalizeFilename(filename)
+
+		if matchesAny(excludeGlobs, filename) {
+			return false
+		}
+
+		if len(includeGlobs) == 0 {
+			return true
+		}
+
+		return matchesAny(includeGlobs, filename)
+	}
+
+	// A directory should be kept if there is any chance that it may
+	// contain a file that should be included.
+	f.isDirInclude = func(filename string) bool {
+		filename = normalizeFilename(filename)
+
+		if matchesAny(excludeGlobs, filename) {
+			return false
+		}
+
+		return true
+	}
+
+	return f, nil
+}
+
+// Match returns whether filename should be included, given whether it
+// represents a directory or not.
+func (f *FilenameFilter) Match(filename string, isDir bool) bool {
+	if f == nil {
+		return true
+	}
+	if isDir {
+		return f.isDirInclude(filename)
+	}
+	return f.shouldInclude(filename)
+}
+
+func matchesAny(globs []glob.Glob, filename string) bool {
+	for _, g := range globs {
+		if g.Match(filename) {
+			return true
+		}
+	}
+	return false
+}
+
+func getGlob(pattern string) (glob.Glob, error) {
+	pattern = normalizeFilename(pattern)
+	return glob.Compile(pattern, '/')
+}
+
+func normalizeFilename(filename string) string {
+	filename = filepath.ToSlash(filename)
+	return strings.TrimPrefix(filename, "/")
+}
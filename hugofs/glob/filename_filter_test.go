@@ -0,0 +1,55 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package glob
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilenameFilter(t *testing.T) {
+	assert := require.New(t)
+
+	f, err := NewFilenameFilter([]string{"**.html", "**.scss"}, []string{"**/node_modules/**"})
+	assert.NoError(err)
+
+	assert.True(f.Match("index.html", false))
+	assert.True(f.Match("scss/main.scss", false))
+	assert.False(f.Match("README.md", false))
+	assert.False(f.Match("assets/node_modules/foo.scss", false))
+
+	f, err = NewFilenameFilter(nil, []string{"*.txt"})
+	assert.NoError(err)
+	assert.True(f.Match("index.html", false))
+	assert.False(f.Match("notes.txt", false))
+
+	empty, err := NewFilenameFilter(nil, nil)
+	assert.NoError(err)
+	assert.Nil(empty)
+	assert.True(empty.Match("anything", false))
+}
+
+// An exclude always wins over an include, regardless of which was
+// declared first -- there is no later-wins precedence between the two
+// lists, unlike e.g. .gitignore's "!pattern" re-include semantics.
+func TestFilenameFilterExcludeWins(t *testing.T) {
+	assert := require.New(t)
+
+	f, err := NewFilenameFilter([]string{"**/keep/**"}, []string{"**/keep/secret.md"})
+	assert.NoError(err)
+
+	assert.True(f.Match("content/keep/page.md", false))
+	assert.False(f.Match("content/keep/secret.md", false))
+}
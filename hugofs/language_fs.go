@@ -16,6 +16,7 @@ package hugofs
 import (
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"syscall"
 	"time"
@@ -131,15 +132,15 @@ func (f *LingoDir) Close() error {
 }
 
 func (f *LingoDir) Name() string {
-	panic("not implemented")
+	return f.dirname
 }
 
 func (f *LingoDir) Read(p []byte) (n int, err error) {
-	panic("not implemented")
+	return 0, &os.PathError{Op: "read", Path: f.dirname, Err: errors.New("is a directory")}
 }
 
 func (f *LingoDir) ReadAt(p []byte, off int64) (n int, err error) {
-	panic("not implemented")
+	return 0, &os.PathError{Op: "read", Path: f.dirname, Err: errors.New("is a directory")}
 }
 
 func (f *LingoDir) Readdir(count int) ([]os.FileInfo, error) {
@@ -160,31 +161,31 @@ func (f *LingoDir) Readdirnames(count int) ([]string, error) {
 }
 
 func (f *LingoDir) Seek(offset int64, whence int) (int64, error) {
-	panic("not implemented")
+	return 0, &os.PathError{Op: "seek", Path: f.dirname, Err: errors.New("is a directory")}
 }
 
 func (f *LingoDir) Stat() (os.FileInfo, error) {
-	panic("not implemented")
+	return f.fi, nil
 }
 
 func (f *LingoDir) Sync() error {
-	panic("not implemented")
+	return nil
 }
 
 func (f *LingoDir) Truncate(size int64) error {
-	panic("not implemented")
+	return syscall.EPERM
 }
 
 func (f *LingoDir) Write(p []byte) (n int, err error) {
-	panic("not implemented")
+	return 0, syscall.EPERM
 }
 
 func (f *LingoDir) WriteAt(p []byte, off int64) (n int, err error) {
-	panic("not implemented")
+	return 0, syscall.EPERM
 }
 
 func (f *LingoDir) WriteString(s string) (ret int, err error) {
-	panic("not implemented")
+	return 0, syscall.EPERM
 }
 
 type LanguageFs struct {
@@ -202,18 +203,27 @@ func (fs *LanguageFs) Chtimes(n string, a, m time.Time) error {
 	return syscall.EPERM
 }
 
-// TODO(bep) mod lstat
 func (fs *LanguageFs) LstatIfPossible(name string) (os.FileInfo, bool, error) {
-	fi, _, err := fs.pickFirst(name)
+	candidates, isDir, err := fs.root.fileCandidates(name)
 	if err != nil {
+		if err == os.ErrNotExist {
+			return nil, false, &os.PathError{Op: "lstat", Path: name, Err: os.ErrNotExist}
+		}
 		return nil, false, err
 	}
-	if fi.IsDir() {
-		return decorateFileInfo(fs, fs.getOpener(name), fi, "", "", ""), false, nil
+
+	if isDir {
+		return decorateFileInfo(fs.root, fs.root.getOpener(name), candidates[0].fi, "", "", ""), false, nil
 	}
 
-	return nil, false, errors.Errorf("lstat: files not supported: %q", name)
+	best := bestLangFileCandidate(candidates)
 
+	return NewFileMetaInfo(best.fi, FileMeta{
+		metaKeyLang:           best.lang,
+		"weight":              best.weight,
+		"translationBaseName": best.translationBaseName,
+		metaKeyOpener:         fs.root.getOpener(name),
+	}), false, nil
 }
 
 func (fs *LanguageFs) Mkdir(n string, p os.FileMode) error {
@@ -229,29 +239,37 @@ func (fs *LanguageFs) Name() string {
 }
 
 func (fs *LanguageFs) Open(name string) (afero.File, error) {
-	fi, lfs, err := fs.pickFirst(name)
+	candidates, isDir, err := fs.root.fileCandidates(name)
 	if err != nil {
+		if err == os.ErrNotExist {
+			return nil, &os.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
+		}
 		return nil, err
 	}
 
-	if !fi.IsDir() {
-		panic("currently only dirs in here")
+	if isDir {
+		return &LingoDir{
+			fs:      fs.root,
+			fi:      candidates[0].fi,
+			dirname: name,
+		}, nil
 	}
 
-	return &LingoDir{
-		fs:      lfs,
-		fi:      fi,
-		dirname: name,
-	}, nil
-
+	return fs.root.openUnionFile(name, candidates)
 }
 
+// OpenFile only supports read-only opens; this is a read-only filesystem.
 func (fs *LanguageFs) OpenFile(name string, flag int, perm os.FileMode) (afero.File, error) {
-	panic("not implemented")
+	if flag&(os.O_WRONLY|os.O_RDWR|os.O_CREATE|os.O_APPEND|os.O_TRUNC) != 0 {
+		return nil, syscall.EPERM
+	}
+	return fs.Open(name)
 }
 
+// ReadDir returns the merged, weight-deduplicated directory listing for name,
+// see readDirs.
 func (fs *LanguageFs) ReadDir(name string) ([]os.FileInfo, error) {
-	panic("not implemented")
+	return fs.root.readDirs(name, -1)
 }
 
 func (fs *LanguageFs) Remove(n string) error {
@@ -385,27 +403,129 @@ func (fs *LanguageFs) filterDuplicates(fis []os.FileInfo) []os.FileInfo {
 	return fis
 }
 
-func (fs *LanguageFs) pickFirst(name string) (os.FileInfo, *LanguageFs, error) {
+// langFileCandidate is one language layer's match for a given virtual path.
+type langFileCandidate struct {
+	fi                  os.FileInfo
+	match               *LanguageFs
+	weight              int
+	lang                string
+	translationBaseName string
+}
+
+// fileCandidates walks every language layer looking for name, applying the
+// same weight rules as applyMeta. If name is a directory, every layer that
+// has it is returned (the caller merges them via readDirs); if it is a
+// regular file, one candidate per layer that has a matching name is
+// returned, ready to be ranked by bestLangFileCandidate or stacked by
+// openUnionFile.
+func (fs *LanguageFs) fileCandidates(name string) (candidates []langFileCandidate, isDir bool, err error) {
 	current := fs
 	for current != nil {
-		fi, err := current.source.Fs().Stat(name)
-		if err == nil {
-			// Gotta match!
-			return fi, current, nil
-		}
-
-		if !os.IsNotExist(err) {
+		fi, ferr := current.source.Fs().Stat(name)
+		if ferr == nil {
+			if fi.IsDir() {
+				isDir = true
+				candidates = append(candidates, langFileCandidate{fi: fi, match: current})
+			} else {
+				lang, translationBaseName := fs.langInfoFrom(fi.Name())
+				weight := 0
+				if lang != "" {
+					weight = 1
+					if lang == current.source.Lang() {
+						// Give priority to myfile.sv.txt inside the sv filesystem.
+						weight++
+					}
+				} else {
+					lang = current.source.Lang()
+				}
+				candidates = append(candidates, langFileCandidate{
+					fi:                  fi,
+					match:               current,
+					weight:              weight,
+					lang:                lang,
+					translationBaseName: translationBaseName,
+				})
+			}
+		} else if !os.IsNotExist(ferr) {
 			// Real error
-			return nil, nil, err
+			return nil, false, ferr
 		}
 
-		// Continue
 		current = current.child
+	}
+
+	if len(candidates) == 0 {
+		return nil, false, os.ErrNotExist
+	}
+
+	return
+}
 
+// bestLangFileCandidate returns the highest-weighted candidate, i.e. the one
+// that would win filterDuplicates.
+func bestLangFileCandidate(candidates []langFileCandidate) langFileCandidate {
+	best := candidates[0]
+	for _, c := range candidates[1:] {
+		if c.weight > best.weight {
+			best = c
+		}
 	}
+	return best
+}
+
+// openUnionFile opens every layer's copy of name and stacks them, lowest
+// weight first, into a chain of afero.UnionFile so the returned file reads
+// and stats as the highest-weighted layer, falling back to the others.
+func (fs *LanguageFs) openUnionFile(name string, candidates []langFileCandidate) (afero.File, error) {
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].weight < candidates[j].weight
+	})
 
-	// Not found
-	return nil, nil, os.ErrNotExist
+	var result afero.File
+	for _, c := range candidates {
+		f, err := c.match.source.Fs().Open(name)
+		if err != nil {
+			return nil, err
+		}
+		if result == nil {
+			result = f
+		} else {
+			result = afero.NewUnionFile(result, f)
+		}
+	}
+
+	best := candidates[len(candidates)-1]
+
+	return &lingoFile{
+		File:                result,
+		fi:                  best.fi,
+		lang:                best.lang,
+		translationBaseName: best.translationBaseName,
+		weight:              best.weight,
+		opener:              fs.root.getOpener(name),
+	}, nil
+}
+
+// lingoFile decorates the afero.File returned for a regular file with the
+// same FileMeta (lang, weight, translationBaseName) that directory entries
+// get from applyMeta.
+type lingoFile struct {
+	afero.File
+
+	fi                  os.FileInfo
+	lang                string
+	translationBaseName string
+	weight              int
+	opener              func() (afero.File, error)
+}
+
+func (f *lingoFile) Stat() (os.FileInfo, error) {
+	return NewFileMetaInfo(f.fi, FileMeta{
+		metaKeyLang:           f.lang,
+		"weight":              f.weight,
+		"translationBaseName": f.translationBaseName,
+		metaKeyOpener:         f.opener,
+	}), nil
 }
 
 func (fs *LanguageFs) readDirs(name string, count int) ([]os.FileInfo, error) {
@@ -474,7 +594,8 @@ type fileOpener struct {
 }
 
 // TODO(bep) mod names, names, names
-//  TODO(bep) mod remove me
+//
+//	TODO(bep) mod remove me
 type lingoFileInfo struct {
 	os.FileInfo
 
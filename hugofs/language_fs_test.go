@@ -15,6 +15,7 @@ package hugofs
 
 import (
 	"fmt"
+	"io/ioutil"
 	"path/filepath"
 	"testing"
 
@@ -72,6 +73,17 @@ func TestLanguageFs(t *testing.T) {
 	assert.Equal("en", enFim.Lang())
 	assert.Equal("sv", svFim.Lang())
 
+	// Open should now also work for regular files, picking the
+	// highest-weighted layer's copy.
+	f, err := lfs.Open(filepath.FromSlash("blog/lingo.sv.txt"))
+	assert.NoError(err)
+	b, err := ioutil.ReadAll(f)
+	assert.NoError(err)
+	assert.Equal("lingo.sv.txt", string(b))
+	fi, err := f.Stat()
+	assert.NoError(err)
+	assert.Equal("sv", fi.(FileMetaInfo).Meta().Lang())
+	assert.NoError(f.Close())
 }
 
 /*
@@ -129,7 +141,7 @@ func TestLanguageRootMapping(t *testing.T) {
 	assert.Equal(2, len(dirs))
 
 	for _, dir := range dirs {
-		fmt.Println(">>> DIR", dir )
+		fmt.Println(">>> DIR", dir)
 	}
 
 }
@@ -0,0 +1,180 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugofs
+
+import (
+	"os"
+	"syscall"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+// OverlayFs stacks a list of filesystems in priority order, modeled on
+// afero's CopyOnWriteFs, but supporting any number of layers instead of
+// just two, e.g. one per module in a Hugo module graph. The first layer
+// is the only one ever written to; all layers are consulted, in order,
+// for reads, so a lookup transparently walks project -> module A ->
+// module B -> ... until a match is found.
+type OverlayFs struct {
+	// Layers, highest priority (e.g. the project) first.
+	layers []afero.Fs
+}
+
+// NewOverlayFs creates a new OverlayFs stacking the given layers in the
+// order given, the first layer having the highest read priority. Create,
+// Mkdir, MkdirAll and OpenFile with a write flag go to layers[0]; Remove,
+// RemoveAll, Rename, Chmod and Chtimes always fail with syscall.EROFS.
+func NewOverlayFs(layers ...afero.Fs) *OverlayFs {
+	return &OverlayFs{layers: layers}
+}
+
+func (ofs *OverlayFs) writable() afero.Fs {
+	if len(ofs.layers) == 0 {
+		return NoOpFs
+	}
+	return ofs.layers[0]
+}
+
+func (ofs *OverlayFs) Chtimes(name string, atime, mtime time.Time) error {
+	return syscall.EROFS
+}
+
+func (ofs *OverlayFs) Chmod(name string, mode os.FileMode) error {
+	return syscall.EROFS
+}
+
+// Stat returns the first match in the stacked layers.
+func (ofs *OverlayFs) Stat(name string) (os.FileInfo, error) {
+	for _, fs := range ofs.layers {
+		fi, err := fs.Stat(name)
+		if err == nil {
+			return fi, nil
+		}
+		if !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+	return nil, &os.PathError{Op: "stat", Path: name, Err: os.ErrNotExist}
+}
+
+// LstatIfPossible returns the first match in the stacked layers, using
+// Lstat when the underlying layer supports it.
+func (ofs *OverlayFs) LstatIfPossible(name string) (os.FileInfo, bool, error) {
+	for _, fs := range ofs.layers {
+		var (
+			fi  os.FileInfo
+			b   bool
+			err error
+		)
+		if lst, ok := fs.(afero.Lstater); ok {
+			fi, b, err = lst.LstatIfPossible(name)
+		} else {
+			fi, err = fs.Stat(name)
+		}
+		if err == nil {
+			return fi, b, nil
+		}
+		if !os.IsNotExist(err) {
+			return nil, false, err
+		}
+	}
+	return nil, false, &os.PathError{Op: "lstat", Path: name, Err: os.ErrNotExist}
+}
+
+// Open opens name for reading. If name is a directory present in more than
+// one layer, the returned afero.File merges the directory listings of every
+// layer that has it, with entries from higher-priority layers winning on
+// name collisions -- the same semantics afero.UnionFile gives a two-layer
+// afero.CopyOnWriteFs, just folded across N layers.
+func (ofs *OverlayFs) Open(name string) (afero.File, error) {
+	var files []afero.File
+
+	for _, fs := range ofs.layers {
+		f, err := fs.Open(name)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+		files = append(files, f)
+	}
+
+	if len(files) == 0 {
+		return nil, &os.PathError{Op: "open", Path: name, Err: os.ErrNotExist}
+	}
+
+	fi, err := files[0].Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	if !fi.IsDir() || len(files) == 1 {
+		// Close any lower-priority duplicates; only the winner is used.
+		for _, f := range files[1:] {
+			f.Close()
+		}
+		return files[0], nil
+	}
+
+	// Fold the per-layer directories into one union. afero.NewUnionFile's
+	// second argument (the "layer") wins over its first (the "base"), so
+	// building left to right with files[0] as the innermost layer keeps
+	// it -- and every higher-priority file folded in after it -- on top
+	// of every lower-priority one.
+	merged := files[0]
+	for i := 1; i < len(files); i++ {
+		merged = afero.NewUnionFile(files[i], merged)
+	}
+
+	return merged, nil
+}
+
+// OpenFile opens read-only requests through Open; any write flag sends the
+// call to the top layer, layers[0].
+func (ofs *OverlayFs) OpenFile(name string, flag int, perm os.FileMode) (afero.File, error) {
+	if flag&(os.O_WRONLY|os.O_RDWR|os.O_CREATE|os.O_APPEND|os.O_TRUNC) != 0 {
+		return ofs.writable().OpenFile(name, flag, perm)
+	}
+	return ofs.Open(name)
+}
+
+func (ofs *OverlayFs) Name() string {
+	return "OverlayFs"
+}
+
+func (ofs *OverlayFs) Create(name string) (afero.File, error) {
+	return ofs.writable().Create(name)
+}
+
+func (ofs *OverlayFs) Mkdir(name string, perm os.FileMode) error {
+	return ofs.writable().Mkdir(name, perm)
+}
+
+func (ofs *OverlayFs) MkdirAll(path string, perm os.FileMode) error {
+	return ofs.writable().MkdirAll(path, perm)
+}
+
+func (ofs *OverlayFs) Remove(name string) error {
+	return syscall.EROFS
+}
+
+func (ofs *OverlayFs) RemoveAll(path string) error {
+	return syscall.EROFS
+}
+
+func (ofs *OverlayFs) Rename(oldname, newname string) error {
+	return syscall.EROFS
+}
@@ -0,0 +1,95 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugofs
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOverlayFs(t *testing.T) {
+	assert := require.New(t)
+
+	project := afero.NewMemMapFs()
+	theme := afero.NewMemMapFs()
+
+	assert.NoError(afero.WriteFile(theme, "partials/header.html", []byte("theme header"), 0755))
+	assert.NoError(afero.WriteFile(theme, "partials/footer.html", []byte("theme footer"), 0755))
+	assert.NoError(afero.WriteFile(project, "partials/header.html", []byte("project header"), 0755))
+
+	ofs := NewOverlayFs(project, theme)
+
+	f, err := ofs.Open("partials/header.html")
+	assert.NoError(err)
+	b, err := ioutil.ReadAll(f)
+	assert.NoError(err)
+	assert.Equal("project header", string(b))
+	f.Close()
+
+	d, err := ofs.Open("partials")
+	assert.NoError(err)
+	names, err := d.Readdirnames(-1)
+	assert.NoError(err)
+	assert.ElementsMatch([]string{"header.html", "footer.html"}, names)
+	d.Close()
+
+	_, err = ofs.Create("partials/new.html")
+	assert.NoError(err)
+	fi, err := project.Stat("partials/new.html")
+	assert.NoError(err)
+	assert.NotNil(fi)
+}
+
+// A name collision inside a merged directory listing must resolve to the
+// higher-priority layer's entry, not the lower-priority one.
+func TestOverlayFsDirectoryCollisionWinner(t *testing.T) {
+	assert := require.New(t)
+
+	project := afero.NewMemMapFs()
+	theme := afero.NewMemMapFs()
+
+	assert.NoError(afero.WriteFile(project, "partials/header.html", []byte("project"), 0755))
+	assert.NoError(afero.WriteFile(theme, "partials/header.html", []byte("theme header, much longer content"), 0755))
+
+	ofs := NewOverlayFs(project, theme)
+
+	d, err := ofs.Open("partials")
+	assert.NoError(err)
+	defer d.Close()
+
+	fis, err := d.Readdir(-1)
+	assert.NoError(err)
+	assert.Len(fis, 1)
+	assert.Equal(int64(len("project")), fis[0].Size())
+}
+
+func TestOverlayFsRealFilename(t *testing.T) {
+	assert := require.New(t)
+
+	fs := afero.NewMemMapFs()
+	assert.NoError(afero.WriteFile(fs, "theme/partials/header.html", []byte("theme header"), 0755))
+
+	theme := NewBasePathRealFilenameFs(afero.NewBasePathFs(fs, "theme").(*afero.BasePathFs))
+	project := afero.NewMemMapFs()
+
+	ofs := NewOverlayFs(project, theme)
+
+	fi, err := ofs.Stat("partials/header.html")
+	assert.NoError(err)
+	m := fi.(FileMetaInfo).Meta()
+	assert.Equal("theme/partials/header.html", m.Filename())
+}
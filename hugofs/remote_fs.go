@@ -0,0 +1,129 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugofs
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// RemoteCacheDir returns the root directory used to cache fetched remote
+// mount content, honouring $HUGO_CACHEDIR if set. Sibling to
+// ModuleCacheDir's cache root, but kept in its own subtree since remote
+// mounts are keyed by URL rather than module path@version.
+func RemoteCacheDir() string {
+	if d := os.Getenv(hugoCacheDirEnvKey); d != "" {
+		return filepath.Join(d, "remote")
+	}
+	return filepath.Join(os.TempDir(), "hugo_cache", "remote")
+}
+
+// FetchRemote downloads rawURL into a stable, per-URL cache directory below
+// RemoteCacheDir, reusing the cached copy -- without touching the network at
+// all -- whenever it is younger than maxAge. Once maxAge has passed, it
+// revalidates with the origin server using the previous response's ETag/
+// Last-Modified header before falling back to a full re-download, so an
+// unchanged remote resource never needs a full re-fetch. A maxAge of 0 always
+// revalidates.
+//
+// It returns the directory holding the cached file and the file's base name,
+// so the caller can mount dir as if it were a local Mount.Source.
+func FetchRemote(client *http.Client, rawURL string, maxAge time.Duration) (dir string, filename string, err error) {
+	sum := sha1.Sum([]byte(rawURL))
+	dir = filepath.Join(RemoteCacheDir(), hex.EncodeToString(sum[:]))
+	if err = os.MkdirAll(dir, 0777); err != nil {
+		return "", "", err
+	}
+
+	filename = filepath.Base(rawURL)
+	if filename == "" || filename == "." || filename == string(os.PathSeparator) {
+		filename = "index"
+	}
+
+	target := filepath.Join(dir, filename)
+	metaFilename := target + ".hugo_meta"
+
+	if fi, statErr := os.Stat(target); statErr == nil && maxAge > 0 && time.Since(fi.ModTime()) < maxAge {
+		return dir, filename, nil
+	}
+
+	req, err := http.NewRequest(http.MethodGet, rawURL, nil)
+	if err != nil {
+		return "", "", err
+	}
+
+	if meta, metaErr := ioutil.ReadFile(metaFilename); metaErr == nil {
+		for _, line := range strings.Split(string(meta), "\n") {
+			switch {
+			case strings.HasPrefix(line, "ETag: "):
+				req.Header.Set("If-None-Match", strings.TrimPrefix(line, "ETag: "))
+			case strings.HasPrefix(line, "Last-Modified: "):
+				req.Header.Set("If-Modified-Since", strings.TrimPrefix(line, "Last-Modified: "))
+			}
+		}
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		if _, statErr := os.Stat(target); statErr == nil {
+			// The origin is unreachable; serve whatever we have cached
+			// rather than failing the build outright.
+			return dir, filename, nil
+		}
+		return "", "", err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusNotModified:
+		now := time.Now()
+		_ = os.Chtimes(target, now, now)
+		return dir, filename, nil
+	case http.StatusOK:
+		f, err := os.Create(target)
+		if err != nil {
+			return "", "", err
+		}
+		_, err = io.Copy(f, resp.Body)
+		f.Close()
+		if err != nil {
+			return "", "", err
+		}
+
+		var meta strings.Builder
+		if etag := resp.Header.Get("ETag"); etag != "" {
+			fmt.Fprintf(&meta, "ETag: %s\n", etag)
+		}
+		if lm := resp.Header.Get("Last-Modified"); lm != "" {
+			fmt.Fprintf(&meta, "Last-Modified: %s\n", lm)
+		}
+		if meta.Len() > 0 {
+			_ = ioutil.WriteFile(metaFilename, []byte(meta.String()), 0666)
+		}
+
+		return dir, filename, nil
+	default:
+		return "", "", errors.Errorf("failed to fetch %q: %s", rawURL, resp.Status)
+	}
+}
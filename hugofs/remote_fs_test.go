@@ -0,0 +1,76 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugofs
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFetchRemote(t *testing.T) {
+	assert := require.New(t)
+
+	var requests int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("If-None-Match") == "v1" {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", "v1")
+		w.Write([]byte("hello remote"))
+	}))
+	defer srv.Close()
+
+	tmp, err := ioutil.TempDir("", "hugo-remote-fetch")
+	assert.NoError(err)
+	defer os.RemoveAll(tmp)
+	assert.NoError(os.Setenv(hugoCacheDirEnvKey, tmp))
+	defer os.Unsetenv(hugoCacheDirEnvKey)
+
+	rawURL := srv.URL + "/data.json"
+
+	dir, filename, err := FetchRemote(srv.Client(), rawURL, 0)
+	assert.NoError(err)
+	assert.Equal("data.json", filename)
+	assert.Equal(1, requests)
+
+	b, err := ioutil.ReadFile(filepath.Join(dir, filename))
+	assert.NoError(err)
+	assert.Equal("hello remote", string(b))
+
+	// A second fetch with maxAge 0 revalidates, gets a 304, and keeps the
+	// cached content without a full re-download.
+	dir2, filename2, err := FetchRemote(srv.Client(), rawURL, 0)
+	assert.NoError(err)
+	assert.Equal(dir, dir2)
+	assert.Equal(filename, filename2)
+	assert.Equal(2, requests)
+
+	b, err = ioutil.ReadFile(filepath.Join(dir2, filename2))
+	assert.NoError(err)
+	assert.Equal("hello remote", string(b))
+
+	// A long maxAge skips the network entirely.
+	_, _, err = FetchRemote(srv.Client(), rawURL, time.Hour)
+	assert.NoError(err)
+	assert.Equal(2, requests)
+}
@@ -14,13 +14,16 @@
 package hugofs
 
 import (
+	"fmt"
 	"os"
 	"path"
 	"path/filepath"
 	"strings"
+	"sync"
 
 	"github.com/pkg/errors"
 
+	"github.com/gohugoio/hugo/hugofs/glob"
 	radix "github.com/hashicorp/go-immutable-radix"
 	"github.com/spf13/afero"
 )
@@ -30,10 +33,18 @@ var filepathSeparator = string(filepath.Separator)
 // A RootMappingFs maps several roots into one. Note that the root of this filesystem
 // is directories only, and they will be returned in Readdir and Readdirnames
 // in the order given.
+//
+// More than one RootMapping may share the same From root, in which case they
+// overlay each other in declared order: the first mapping that has a given
+// file wins, and the rest are reported as shadowed, see Conflicts.
 type RootMappingFs struct {
 	afero.Fs
 	rootMapToReal *radix.Node
 	virtualRoots  []RootMapping
+
+	conflictsMu   sync.Mutex
+	conflicts     []RootMappingConflict
+	conflictsSeen map[string]bool
 }
 
 type rootMappingFile struct {
@@ -41,6 +52,27 @@ type rootMappingFile struct {
 	fs   *RootMappingFs
 	name string
 	rm   RootMapping
+
+	// Set instead of File/rm when name resolves to a directory present in
+	// more than one overlaid root; Readdir then merges their listings.
+	dirFiles []afero.File
+	dirRoots []RootMapping
+}
+
+// RootMappingConflict describes a file or directory entry that exists in
+// more than one overlaid RootMapping below the same From root. Winner and
+// Losers hold the real (To-resolved) filenames; Losers is ordered by
+// descending precedence, i.e. the order their owning mounts were declared.
+type RootMappingConflict struct {
+	// Path is the virtual path, relative to the RootMappingFs, where the
+	// conflict was observed.
+	Path string
+
+	// Winner is the real filename of the mount that shadows the others.
+	Winner string
+
+	// Losers are the real filenames of the mount(s) hidden by Winner.
+	Losers []string
 }
 
 type RootMapping struct {
@@ -49,6 +81,25 @@ type RootMapping struct {
 
 	// Metadata
 	Lang string
+
+	// Filter, when set, decides which files below From are visible through
+	// this root mapping. Files not matching are hidden as if they did not
+	// exist, analogous to afero's RegexpFs.
+	Filter *glob.FilenameFilter
+
+	// Fs, when set, is used to resolve To instead of the RootMappingFs's own
+	// shared Fs. This lets individual roots be backed by a filesystem of
+	// their own, e.g. one rooted at a fetched-and-cached remote mount.
+	Fs afero.Fs
+}
+
+// fs returns the filesystem this mapping's To should be resolved against:
+// r.Fs if set, else the RootMappingFs's shared fallback.
+func (r RootMapping) fs(fallback afero.Fs) afero.Fs {
+	if r.Fs != nil {
+		return r.Fs
+	}
+	return fallback
 }
 
 func (r RootMapping) rootKey() string {
@@ -64,6 +115,21 @@ func (r RootMapping) filename(name string) string {
 	return filepath.Join(r.To, strings.TrimPrefix(name, r.From))
 }
 
+// relPath returns name relative to the root's From, suitable for matching
+// against Filter's include/exclude patterns.
+func (r RootMapping) relPath(name string) string {
+	return strings.TrimPrefix(strings.TrimPrefix(name, r.From), filepathSeparator)
+}
+
+// isFiltered reports whether name (an absolute virtual path below From)
+// should be hidden by this root mapping's Filter.
+func (r RootMapping) isFiltered(name string, isDir bool) bool {
+	if r.Filter == nil {
+		return false
+	}
+	return !r.Filter.Match(r.relPath(name), isDir)
+}
+
 func (rm *RootMapping) clean() {
 	rm.From = filepath.Clean(rm.From)
 	rm.To = filepath.Clean(rm.To)
@@ -73,10 +139,34 @@ func (rm *RootMapping) clean() {
 // of root mappings with some optional metadata about the root.
 // Note that From represents a virtual root that maps to the actual filename in To.
 func NewRootMappingFs(fs afero.Fs, rms ...RootMapping) (*RootMappingFs, error) {
+	return newRootMappingFs(fs, strings.ToLower, rms...)
+}
+
+// NewRootMappingFsWithKeyFold is a variant of NewRootMappingFs that lets the
+// caller override the case-folding function used to detect From roots that
+// would collide on a case-insensitive filesystem. Pass the identity
+// function (func(s string) string { return s }) to disable the check
+// entirely on a filesystem known to be case-sensitive.
+func NewRootMappingFsWithKeyFold(fs afero.Fs, keyFold func(string) string, rms ...RootMapping) (*RootMappingFs, error) {
+	return newRootMappingFs(fs, keyFold, rms...)
+}
+
+func newRootMappingFs(fs afero.Fs, keyFold func(string) string, rms ...RootMapping) (*RootMappingFs, error) {
 	rootMapToReal := radix.New().Txn()
 
+	// Detect From roots that are spelled differently but would collide on
+	// a case-insensitive filesystem (macOS, Windows), where they would
+	// otherwise silently shadow each other instead of overlaying as
+	// intended -- overlaying is only meant for mappings that share the
+	// exact same From.
+	folded := make(map[string][]RootMapping)
+
 	for _, rm := range rms {
 		(&rm).clean()
+
+		fk := keyFold(rm.rootKey())
+		folded[fk] = append(folded[fk], rm)
+
 		key := []byte(rm.rootKey())
 		var mappings []RootMapping
 		v, found := rootMapToReal.Get(key)
@@ -88,10 +178,28 @@ func NewRootMappingFs(fs afero.Fs, rms ...RootMapping) (*RootMappingFs, error) {
 		rootMapToReal.Insert(key, mappings)
 	}
 
+	for fk, group := range folded {
+		seen := make(map[string]bool)
+		var distinct []RootMapping
+		for _, rm := range group {
+			if !seen[rm.rootKey()] {
+				seen[rm.rootKey()] = true
+				distinct = append(distinct, rm)
+			}
+		}
+		if len(distinct) > 1 {
+			return nil, &RootMappingCollisionError{Key: fk, Mappings: distinct}
+		}
+	}
+
 	if rfs, ok := fs.(*afero.BasePathFs); ok {
 		fs = NewBasePathRealFilenameFs(rfs)
 	}
 
+	if err := validateRootSymlinks(fs, rms); err != nil {
+		return nil, err
+	}
+
 	rfs := &RootMappingFs{Fs: fs,
 		virtualRoots:  rms,
 		rootMapToReal: rootMapToReal.Commit().Root()}
@@ -99,6 +207,65 @@ func NewRootMappingFs(fs afero.Fs, rms ...RootMapping) (*RootMappingFs, error) {
 	return rfs, nil
 }
 
+// RootMappingCollisionError is returned by NewRootMappingFs when two or
+// more root mappings whose From paths differ only by case would otherwise
+// silently shadow each other on a case-insensitive filesystem.
+type RootMappingCollisionError struct {
+	// Key is the case-folded value the colliding From roots share.
+	Key string
+
+	// Mappings are the colliding roots, in declared order.
+	Mappings []RootMapping
+}
+
+func (e *RootMappingCollisionError) Error() string {
+	froms := make([]string, len(e.Mappings))
+	for i, rm := range e.Mappings {
+		froms[i] = rm.From
+	}
+	return fmt.Sprintf("root mapping collision: %s would all map to %q on a case-insensitive filesystem", strings.Join(froms, ", "), e.Key)
+}
+
+// validateRootSymlinks rejects any root mapping whose To, once symlinks are
+// resolved, escapes the real base directory of fs. This only applies when
+// fs exposes a real OS base path, i.e. is or wraps a *BasePathRealFilenameFs
+// (see NewBasePathRealFilenameFs); for anything else -- e.g. the in-memory
+// filesystems used in tests, or a mount not yet materialized on disk --
+// there is no real path to resolve, so the check is silently skipped.
+func validateRootSymlinks(fs afero.Fs, rms []RootMapping) error {
+	rp, ok := fs.(interface {
+		RealPath(name string) (string, error)
+	})
+	if !ok {
+		return nil
+	}
+
+	baseReal, err := rp.RealPath("")
+	if err != nil {
+		return nil
+	}
+	baseReal, err = filepath.EvalSymlinks(baseReal)
+	if err != nil {
+		return nil
+	}
+
+	for _, rm := range rms {
+		real, err := rp.RealPath(rm.To)
+		if err != nil {
+			continue
+		}
+		resolved, err := filepath.EvalSymlinks(real)
+		if err != nil {
+			continue
+		}
+		if resolved != baseReal && !strings.HasPrefix(resolved, baseReal+string(filepath.Separator)) {
+			return errors.Errorf("root mapping %q -> %q resolves to %q, which is outside of %q", rm.From, rm.To, resolved, baseReal)
+		}
+	}
+
+	return nil
+}
+
 // NewRootMappingFsFromFromTo is a convenicence variant of NewRootMappingFs taking
 // From and To as string pairs.
 func NewRootMappingFsFromFromTo(fs afero.Fs, fromTo ...string) (*RootMappingFs, error) {
@@ -139,21 +306,31 @@ func (fs *RootMappingFs) Stat(name string) (os.FileInfo, error) {
 		return newDirNameOnlyFileInfo(name), nil
 	}
 
-	root, err := fs.getRoot(name)
+	matches, err := fs.matchRoots(name, false)
 	if err != nil {
 		return nil, err
 	}
 
-	filename := root.filename(name)
+	winner := matches[0]
+	shadowed := fs.recordConflict(name, winner.root, matches[1:])
 
-	fi, err := fs.Fs.Stat(filename)
-	if err != nil {
-		return nil, err
-	}
+	filename := winner.root.filename(name)
+	rfs := winner.root.fs(fs.Fs)
+
+	fim := decorateFileInfo(rfs, fs.getOpener(name, winner.root), winner.fi, filename, name, winner.root.Lang)
+	fim.(FileMetaInfo).Meta().setIfNotZero(metaKeyShadowed, shadowed)
 
-	// TODO(bep) mod root
-	return decorateFileInfo(fs.Fs, nil, fi, filename, "", root.Lang), nil
+	return fim, nil
+
+}
 
+// getOpener returns a func that opens name through root, so that FileMeta.Open
+// (and in turn FileMeta.Hash) keeps working no matter how many layers of
+// mount indirection sit between the caller and the real filesystem.
+func (fs *RootMappingFs) getOpener(name string, root RootMapping) func() (afero.File, error) {
+	return func() (afero.File, error) {
+		return root.fs(fs.Fs).Open(root.filename(name))
+	}
 }
 
 // LstatIfPossible returns the os.FileInfo structure describing a given file.
@@ -165,29 +342,139 @@ func (fs *RootMappingFs) LstatIfPossible(name string) (os.FileInfo, bool, error)
 		return newDirNameOnlyFileInfo(name), false, nil
 	}
 
-	root, err := fs.getRoot(name)
+	matches, err := fs.matchRoots(name, true)
 	if err != nil {
 		return nil, false, err
 	}
 
-	filename := root.filename(name)
+	winner := matches[0]
+	shadowed := fs.recordConflict(name, winner.root, matches[1:])
 
-	var b bool
-	var fi os.FileInfo
+	filename := winner.root.filename(name)
+	rfs := winner.root.fs(fs.Fs)
 
-	if ls, ok := fs.Fs.(afero.Lstater); ok {
-		fi, b, err = ls.LstatIfPossible(filename)
-		if err != nil {
-			return nil, b, err
+	fim := decorateFileInfo(rfs, fs.getOpener(name, winner.root), winner.fi, filename, name, winner.root.Lang)
+	fim.(FileMetaInfo).Meta().setIfNotZero(metaKeyShadowed, shadowed)
+
+	return fim, winner.ls, nil
+}
+
+// rootMappingMatch is a single root mapping's hit when resolving name
+// against every RootMapping overlaid below the same From root.
+type rootMappingMatch struct {
+	fi   os.FileInfo
+	root RootMapping
+
+	// Set when the hit came from an afero.Lstater's LstatIfPossible.
+	ls bool
+}
+
+// matchRoots resolves name against each RootMapping overlaid below the same
+// From root, in declared order, returning every non-filtered hit; the first
+// entry is the winner, see Conflicts. If useLstat is set,
+// afero.Lstater is preferred over Stat where available.
+func (fs *RootMappingFs) matchRoots(name string, useLstat bool) ([]rootMappingMatch, error) {
+	roots := fs.getRoots(name)
+	if len(roots) == 0 {
+		return nil, os.ErrNotExist
+	}
+
+	var matches []rootMappingMatch
+
+	for _, root := range roots {
+		filename := root.filename(name)
+		rfs := root.fs(fs.Fs)
+
+		var fi os.FileInfo
+		var ls bool
+		var err error
+
+		if useLstat {
+			if lster, ok := rfs.(afero.Lstater); ok {
+				fi, ls, err = lster.LstatIfPossible(filename)
+			} else {
+				fi, err = rfs.Stat(filename)
+			}
+		} else {
+			fi, err = rfs.Stat(filename)
 		}
 
-	} else {
-		fi, err = fs.Stat(filename)
 		if err != nil {
-			return nil, b, err
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
 		}
+
+		if root.isFiltered(name, fi.IsDir()) {
+			continue
+		}
+
+		matches = append(matches, rootMappingMatch{fi: fi, root: root, ls: ls})
+	}
+
+	if len(matches) == 0 {
+		return nil, os.ErrNotExist
 	}
-	return decorateFileInfo(fs.Fs, nil, fi, filename, "", root.Lang), b, nil
+
+	return matches, nil
+}
+
+// recordConflict appends a RootMappingConflict for path if losers (matches
+// shadowed by winnerRoot) is non-empty, and returns their real filenames so
+// the caller can also attach them to the winning FileInfo's Meta(), see
+// FileMeta.Shadowed. A real build stats and lists the same shadowed path
+// many times over, so conflicts already recorded for the same (path,
+// winner) pair are deduplicated rather than appended again.
+func (fs *RootMappingFs) recordConflict(path string, winnerRoot RootMapping, losers []rootMappingMatch) []string {
+	if len(losers) == 0 {
+		return nil
+	}
+
+	loserFilenames := make([]string, len(losers))
+	for i, l := range losers {
+		loserFilenames[i] = l.root.filename(path)
+	}
+
+	winner := winnerRoot.filename(path)
+
+	fs.conflictsMu.Lock()
+	key := path + "\x00" + winner
+	if fs.conflictsSeen == nil {
+		fs.conflictsSeen = make(map[string]bool)
+	}
+	if !fs.conflictsSeen[key] {
+		fs.conflictsSeen[key] = true
+		fs.conflicts = append(fs.conflicts, RootMappingConflict{
+			Path:   path,
+			Winner: winner,
+			Losers: loserFilenames,
+		})
+	}
+	fs.conflictsMu.Unlock()
+
+	return loserFilenames
+}
+
+// Conflicts returns the files and directories shadowed by an overlaid
+// RootMapping, accumulated so far from Stat, LstatIfPossible, Open and
+// Readdir calls against this filesystem.
+func (fs *RootMappingFs) Conflicts() []RootMappingConflict {
+	fs.conflictsMu.Lock()
+	defer fs.conflictsMu.Unlock()
+	out := make([]RootMappingConflict, len(fs.conflicts))
+	copy(out, fs.conflicts)
+	return out
+}
+
+// Mounts returns the effective, resolved root mappings backing this
+// filesystem, in declared precedence order. Primarily useful for
+// diagnostics, e.g. letting "hugo mod" print what is actually mounted
+// where.
+func (fs *RootMappingFs) Mounts() []RootMapping {
+	out := make([]RootMapping, len(fs.virtualRoots))
+	copy(out, fs.virtualRoots)
+	return out
 }
 
 func (fs *RootMappingFs) isRoot(name string) bool {
@@ -195,22 +482,49 @@ func (fs *RootMappingFs) isRoot(name string) bool {
 
 }
 
-// Open opens the named file for reading.
+// Open opens the named file for reading. If name resolves to a directory in
+// more than one overlaid RootMapping, the returned file merges their
+// listings on Readdir; for a regular file, the first (winning) mapping's
+// copy is opened and the rest are recorded as conflicts, see Conflicts.
 func (fs *RootMappingFs) Open(name string) (afero.File, error) {
 	if fs.isRoot(name) {
 		return &rootMappingFile{name: name, fs: fs}, nil
 	}
-	root, err := fs.getRoot(name)
+
+	matches, err := fs.matchRoots(name, false)
 	if err != nil {
 		return nil, err
 	}
-	filename := root.filename(name)
 
-	f, err := fs.Fs.Open(filename)
+	if matches[0].fi.IsDir() {
+		var dirFiles []afero.File
+		var dirRoots []RootMapping
+		for _, m := range matches {
+			if !m.fi.IsDir() {
+				// A name can't be both a file and a directory; skip a
+				// mismatched mount rather than erroring the whole open.
+				continue
+			}
+			rfs := m.root.fs(fs.Fs)
+			f, err := rfs.Open(m.root.filename(name))
+			if err != nil {
+				return nil, err
+			}
+			dirFiles = append(dirFiles, f)
+			dirRoots = append(dirRoots, m.root)
+		}
+		return &rootMappingFile{name: name, fs: fs, dirFiles: dirFiles, dirRoots: dirRoots}, nil
+	}
+
+	winner := matches[0]
+	fs.recordConflict(name, winner.root, matches[1:])
+
+	rfs := winner.root.fs(fs.Fs)
+	f, err := rfs.Open(winner.root.filename(name))
 	if err != nil {
 		return nil, err
 	}
-	return &rootMappingFile{File: f, name: name, fs: fs, rm: root}, nil
+	return &rootMappingFile{File: f, name: name, fs: fs, rm: winner.root}, nil
 }
 
 func (fs *RootMappingFs) getRoot(name string) (RootMapping, error) {
@@ -236,6 +550,10 @@ func (fs *RootMappingFs) getRoots(name string) []RootMapping {
 }
 
 func (f *rootMappingFile) Readdir(count int) ([]os.FileInfo, error) {
+	if len(f.dirFiles) > 0 {
+		return f.readdirUnion(count)
+	}
+
 	if f.File == nil {
 		dirsn := make([]os.FileInfo, 0)
 		for i := 0; i < len(f.fs.virtualRoots); i++ {
@@ -257,12 +575,66 @@ func (f *rootMappingFile) Readdir(count int) ([]os.FileInfo, error) {
 		return nil, err
 	}
 
-	for i, fi := range fis {
-		fis[i] = decorateFileInfo(f.fs.Fs, nil, fi, "", filepath.Join(f.Name(), fi.Name()), f.rm.Lang)
+	filtered := fis[:0]
+	for _, fi := range fis {
+		childName := filepath.Join(f.Name(), fi.Name())
+		if f.rm.isFiltered(childName, fi.IsDir()) {
+			continue
+		}
+		filtered = append(filtered, decorateFileInfo(f.rm.fs(f.fs.Fs), f.fs.getOpener(childName, f.rm), fi, "", childName, f.rm.Lang))
+	}
+
+	return filtered, nil
+
+}
+
+// readdirUnion merges the child listings of every RootMapping overlaid onto
+// this directory, in declared order: the first mount to produce a given
+// child name wins, and any later mount(s) with a same-named entry are
+// recorded as conflicts, see Conflicts.
+func (f *rootMappingFile) readdirUnion(count int) ([]os.FileInfo, error) {
+	var order []string
+	grouped := make(map[string][]rootMappingMatch)
+
+	for i, df := range f.dirFiles {
+		root := f.dirRoots[i]
+
+		fis, err := df.Readdir(-1)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, fi := range fis {
+			childName := filepath.Join(f.Name(), fi.Name())
+			if root.isFiltered(childName, fi.IsDir()) {
+				continue
+			}
+			if _, found := grouped[fi.Name()]; !found {
+				order = append(order, fi.Name())
+			}
+			grouped[fi.Name()] = append(grouped[fi.Name()], rootMappingMatch{fi: fi, root: root})
+		}
+	}
+
+	out := make([]os.FileInfo, 0, len(order))
+	for _, name := range order {
+		matches := grouped[name]
+		winner := matches[0]
+		childName := filepath.Join(f.Name(), name)
+
+		shadowed := f.fs.recordConflict(childName, winner.root, matches[1:])
+
+		fim := decorateFileInfo(winner.root.fs(f.fs.Fs), f.fs.getOpener(childName, winner.root), winner.fi, "", childName, winner.root.Lang)
+		fim.(FileMetaInfo).Meta().setIfNotZero(metaKeyShadowed, shadowed)
+
+		out = append(out, fim)
 	}
 
-	return fis, nil
+	if count > 0 && count < len(out) {
+		out = out[:count]
+	}
 
+	return out, nil
 }
 
 func (f *rootMappingFile) Readdirnames(count int) ([]string, error) {
@@ -282,8 +654,15 @@ func (f *rootMappingFile) Name() string {
 }
 
 func (f *rootMappingFile) Close() error {
-	if f.File == nil {
-		return nil
+	if f.File != nil {
+		return f.File.Close()
+	}
+
+	var err error
+	for _, df := range f.dirFiles {
+		if cerr := df.Close(); cerr != nil {
+			err = cerr
+		}
 	}
-	return f.File.Close()
+	return err
 }
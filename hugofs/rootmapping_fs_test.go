@@ -19,6 +19,7 @@ import (
 	"path/filepath"
 	"testing"
 
+	"github.com/gohugoio/hugo/hugofs/glob"
 	"github.com/spf13/afero"
 	"github.com/stretchr/testify/require"
 )
@@ -115,6 +116,117 @@ func TestRootMappingFsDirnames(t *testing.T) {
 
 }
 
+func TestRootMappingFsFilter(t *testing.T) {
+	assert := require.New(t)
+	fs := afero.NewMemMapFs()
+
+	assert.NoError(afero.WriteFile(fs, filepath.Join("src", "keep.md"), []byte("keep"), 0755))
+	assert.NoError(afero.WriteFile(fs, filepath.Join("src", "skip.txt"), []byte("skip"), 0755))
+
+	filter, err := glob.NewFilenameFilter([]string{"*.md"}, nil)
+	assert.NoError(err)
+
+	rfs, err := NewRootMappingFs(fs, RootMapping{From: "blog", To: "src", Filter: filter})
+	assert.NoError(err)
+
+	_, err = rfs.Stat(filepath.Join("blog", "keep.md"))
+	assert.NoError(err)
+
+	_, err = rfs.Stat(filepath.Join("blog", "skip.txt"))
+	assert.True(os.IsNotExist(err))
+
+	names, err := afero.ReadDir(rfs, "blog")
+	assert.NoError(err)
+	assert.Equal(1, len(names))
+	assert.Equal("keep.md", names[0].Name())
+}
+
+func TestRootMappingFsCustomFs(t *testing.T) {
+	assert := require.New(t)
+
+	cacheDir, err := ioutil.TempDir("", "hugo-root-mapping-remote")
+	assert.NoError(err)
+	defer os.RemoveAll(cacheDir)
+
+	assert.NoError(afero.WriteFile(afero.NewOsFs(), filepath.Join(cacheDir, "data.json"), []byte("remote content"), 0755))
+
+	remoteFs := NewSecureBasePathFs(afero.NewOsFs(), cacheDir)
+	rm := RootMapping{From: "data/remote", Fs: remoteFs}
+
+	rfs, err := NewRootMappingFs(afero.NewMemMapFs(), rm)
+	assert.NoError(err)
+
+	fi, err := rfs.Stat(filepath.Join("data/remote", "data.json"))
+	assert.NoError(err)
+	m := fi.(FileMetaInfo).Meta()
+	assert.Equal(filepath.Join(cacheDir, "data.json"), m.Filename())
+	assert.Equal(filepath.Join("data/remote", "data.json"), m.Path())
+
+	f, err := rfs.Open(filepath.Join("data/remote", "data.json"))
+	assert.NoError(err)
+	defer f.Close()
+	c, err := ioutil.ReadAll(f)
+	assert.NoError(err)
+	assert.Equal("remote content", string(c))
+}
+
+func TestRootMappingFsOverlay(t *testing.T) {
+	assert := require.New(t)
+	fs := afero.NewMemMapFs()
+
+	assert.NoError(afero.WriteFile(fs, filepath.Join("f1t", "shared.txt"), []byte("from f1"), 0755))
+	assert.NoError(afero.WriteFile(fs, filepath.Join("f2t", "shared.txt"), []byte("from f2"), 0755))
+	assert.NoError(afero.WriteFile(fs, filepath.Join("f2t", "f2-only.txt"), []byte("f2 only"), 0755))
+	assert.NoError(afero.WriteFile(fs, filepath.Join("f3t", "shared.txt"), []byte("from f3"), 0755))
+	assert.NoError(afero.WriteFile(fs, filepath.Join("f3t", "f3-only.txt"), []byte("f3 only"), 0755))
+
+	rfs, err := NewRootMappingFs(fs,
+		RootMapping{From: "blog", To: "f1t"},
+		RootMapping{From: "blog", To: "f2t"},
+		RootMapping{From: "blog", To: "f3t"},
+	)
+	assert.NoError(err)
+
+	// Stat/Open on a name present in all three mounts resolves to the
+	// first-declared one.
+	fi, err := rfs.Stat(filepath.Join("blog", "shared.txt"))
+	assert.NoError(err)
+	m := fi.(FileMetaInfo).Meta()
+	assert.Equal(filepath.FromSlash("f1t/shared.txt"), m.Filename())
+	assert.Equal([]string{filepath.FromSlash("f2t/shared.txt"), filepath.FromSlash("f3t/shared.txt")}, m.Shadowed())
+
+	f, err := rfs.Open(filepath.Join("blog", "shared.txt"))
+	assert.NoError(err)
+	defer f.Close()
+	c, err := ioutil.ReadAll(f)
+	assert.NoError(err)
+	assert.Equal("from f1", string(c))
+
+	// Readdir on the shared directory returns a deduplicated union of
+	// children, in declared order. afero.ReadDir would sort by name, so the
+	// directory is read directly.
+	d, err := rfs.Open("blog")
+	assert.NoError(err)
+	defer d.Close()
+	names, err := d.Readdir(-1)
+	assert.NoError(err)
+	var gotNames []string
+	for _, fi := range names {
+		gotNames = append(gotNames, fi.Name())
+	}
+	assert.Equal([]string{"shared.txt", "f2-only.txt", "f3-only.txt"}, gotNames)
+
+	// Conflicts reports the shadowed mounts for the overlapping name. Stat
+	// and Readdir above both observed it, but a (path, winner) pair is only
+	// ever recorded once.
+	conflicts := rfs.Conflicts()
+	assert.Len(conflicts, 1)
+	conflict := conflicts[0]
+	assert.Equal(filepath.Join("blog", "shared.txt"), conflict.Path)
+	assert.Equal(filepath.FromSlash("f1t/shared.txt"), conflict.Winner)
+	assert.Equal([]string{filepath.FromSlash("f2t/shared.txt"), filepath.FromSlash("f3t/shared.txt")}, conflict.Losers)
+}
+
 func TestRootMappingFsOs(t *testing.T) {
 	assert := require.New(t)
 	fs := afero.NewOsFs()
@@ -146,3 +258,94 @@ func TestRootMappingFsOs(t *testing.T) {
 	assert.Equal([]string{"bf1", "cf2", "af3"}, dirnames)
 
 }
+
+func TestRootMappingFsCaseCollision(t *testing.T) {
+	assert := require.New(t)
+	fs := afero.NewMemMapFs()
+
+	assert.NoError(fs.Mkdir("content/Blog", 0755))
+	assert.NoError(fs.Mkdir("content/blog", 0755))
+
+	_, err := NewRootMappingFs(fs,
+		RootMapping{From: "Blog", To: "content/Blog"},
+		RootMapping{From: "blog", To: "content/blog"},
+	)
+	assert.Error(err)
+
+	cerr, ok := err.(*RootMappingCollisionError)
+	assert.True(ok)
+	assert.Equal("blog", cerr.Key)
+	assert.Len(cerr.Mappings, 2)
+}
+
+func TestRootMappingFsCaseCollisionDisabled(t *testing.T) {
+	assert := require.New(t)
+	fs := afero.NewMemMapFs()
+
+	assert.NoError(fs.Mkdir("content/Blog", 0755))
+	assert.NoError(fs.Mkdir("content/blog", 0755))
+
+	identity := func(s string) string { return s }
+
+	rfs, err := NewRootMappingFsWithKeyFold(fs, identity,
+		RootMapping{From: "Blog", To: "content/Blog"},
+		RootMapping{From: "blog", To: "content/blog"},
+	)
+	assert.NoError(err)
+	assert.Len(rfs.Mounts(), 2)
+}
+
+func TestRootMappingFsMounts(t *testing.T) {
+	assert := require.New(t)
+	fs := afero.NewMemMapFs()
+
+	assert.NoError(fs.Mkdir("content/blog", 0755))
+	assert.NoError(fs.Mkdir("content/docs", 0755))
+
+	rfs, err := NewRootMappingFs(fs,
+		RootMapping{From: "blog", To: "content/blog"},
+		RootMapping{From: "docs", To: "content/docs"},
+	)
+	assert.NoError(err)
+
+	mounts := rfs.Mounts()
+	assert.Len(mounts, 2)
+	assert.Equal("blog", mounts[0].From)
+	assert.Equal("docs", mounts[1].From)
+}
+
+func TestRootMappingFsSymlinkEscape(t *testing.T) {
+	assert := require.New(t)
+
+	d, err := ioutil.TempDir("", "hugo-root-mapping-symlink")
+	assert.NoError(err)
+	defer os.RemoveAll(d)
+
+	base := filepath.Join(d, "base")
+	outside := filepath.Join(d, "outside")
+	assert.NoError(os.MkdirAll(filepath.Join(base, "good"), 0755))
+	assert.NoError(os.MkdirAll(outside, 0755))
+	assert.NoError(os.Symlink(outside, filepath.Join(base, "escape")))
+
+	bfs := afero.NewBasePathFs(afero.NewOsFs(), base).(*afero.BasePathFs)
+
+	_, err = NewRootMappingFs(bfs, RootMapping{From: "evil", To: "escape"})
+	assert.Error(err)
+}
+
+func TestRootMappingFsSymlinkNoEscape(t *testing.T) {
+	assert := require.New(t)
+
+	d, err := ioutil.TempDir("", "hugo-root-mapping-symlink-ok")
+	assert.NoError(err)
+	defer os.RemoveAll(d)
+
+	base := filepath.Join(d, "base")
+	assert.NoError(os.MkdirAll(filepath.Join(base, "good"), 0755))
+
+	bfs := afero.NewBasePathFs(afero.NewOsFs(), base).(*afero.BasePathFs)
+
+	rfs, err := NewRootMappingFs(bfs, RootMapping{From: "good", To: "good"})
+	assert.NoError(err)
+	assert.NotNil(rfs)
+}
@@ -0,0 +1,209 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugofs
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+// NewSecureBasePathFs is like afero.NewBasePathFs, but also rejects any path
+// that, once symlinks are resolved, escapes root. A plain BasePathFs only
+// cleans the path syntactically, so a symlink living inside root can still
+// point anywhere on disk; this closes that path-traversal hole.
+//
+// root must exist and be resolvable on the real filesystem. If it isn't
+// (e.g. source is an in-memory filesystem used in tests), symlink checking
+// is skipped and the result behaves exactly like afero.NewBasePathFs.
+func NewSecureBasePathFs(source afero.Fs, root string) afero.Fs {
+	realRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return afero.NewBasePathFs(source, root)
+	}
+
+	return &secureBasePathFs{
+		BasePathFs: afero.NewBasePathFs(source, root).(*afero.BasePathFs),
+		root:       realRoot,
+	}
+}
+
+// secureBasePathFs adds symlink-escape protection on top of afero.BasePathFs.
+type secureBasePathFs struct {
+	*afero.BasePathFs
+	root string
+}
+
+// realPath returns the real, root-relative path for name, or os.ErrPermission
+// if it (or, for a not-yet-existing name such as a Create or Mkdir target,
+// its nearest existing ancestor) resolves via symlinks to somewhere outside
+// root.
+func (fs *secureBasePathFs) realPath(name string) (string, error) {
+	p, err := fs.RealPath(name)
+	if err != nil {
+		return "", err
+	}
+
+	resolved, err := filepath.EvalSymlinks(p)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			return "", err
+		}
+		// name itself doesn't exist yet. A symlink further up its path
+		// could still walk it outside root, so check the nearest existing
+		// ancestor instead of skipping the check entirely.
+		return p, fs.checkAncestor(filepath.Dir(p))
+	}
+
+	if resolved != fs.root && !strings.HasPrefix(resolved, fs.root+string(os.PathSeparator)) {
+		return "", os.ErrPermission
+	}
+
+	return p, nil
+}
+
+// checkAncestor walks up from dir until it finds a component that exists,
+// and verifies that component resolves, via symlinks, to inside fs.root.
+func (fs *secureBasePathFs) checkAncestor(dir string) error {
+	resolved, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			parent := filepath.Dir(dir)
+			if parent == dir {
+				// Reached the filesystem root without finding anything
+				// that exists; let the real call below produce the
+				// natural not-found error.
+				return nil
+			}
+			return fs.checkAncestor(parent)
+		}
+		return err
+	}
+
+	if resolved != fs.root && !strings.HasPrefix(resolved, fs.root+string(os.PathSeparator)) {
+		return os.ErrPermission
+	}
+
+	return nil
+}
+
+func (fs *secureBasePathFs) Open(name string) (afero.File, error) {
+	if _, err := fs.realPath(name); err != nil {
+		return nil, &os.PathError{Op: "open", Path: name, Err: err}
+	}
+	return fs.BasePathFs.Open(name)
+}
+
+func (fs *secureBasePathFs) OpenFile(name string, flag int, perm os.FileMode) (afero.File, error) {
+	if _, err := fs.realPath(name); err != nil {
+		return nil, &os.PathError{Op: "open", Path: name, Err: err}
+	}
+	return fs.BasePathFs.OpenFile(name, flag, perm)
+}
+
+func (fs *secureBasePathFs) Stat(name string) (os.FileInfo, error) {
+	filename, err := fs.realPath(name)
+	if err != nil {
+		return nil, &os.PathError{Op: "stat", Path: name, Err: err}
+	}
+
+	fi, err := fs.BasePathFs.Stat(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return decorateFileInfo(fs, fs.getOpener(name), fi, filename, "", ""), nil
+}
+
+func (fs *secureBasePathFs) getOpener(name string) func() (afero.File, error) {
+	return func() (afero.File, error) {
+		return fs.Open(name)
+	}
+}
+
+func (fs *secureBasePathFs) LstatIfPossible(name string) (os.FileInfo, bool, error) {
+	filename, err := fs.realPath(name)
+	if err != nil {
+		return nil, false, &os.PathError{Op: "lstat", Path: name, Err: err}
+	}
+
+	fi, b, err := fs.BasePathFs.LstatIfPossible(name)
+	if err != nil {
+		return nil, b, err
+	}
+
+	return decorateFileInfo(fs, fs.getOpener(name), fi, filename, "", ""), b, nil
+}
+
+func (fs *secureBasePathFs) Create(name string) (afero.File, error) {
+	if _, err := fs.realPath(name); err != nil {
+		return nil, &os.PathError{Op: "create", Path: name, Err: err}
+	}
+	return fs.BasePathFs.Create(name)
+}
+
+func (fs *secureBasePathFs) Mkdir(name string, perm os.FileMode) error {
+	if _, err := fs.realPath(name); err != nil {
+		return &os.PathError{Op: "mkdir", Path: name, Err: err}
+	}
+	return fs.BasePathFs.Mkdir(name, perm)
+}
+
+func (fs *secureBasePathFs) MkdirAll(path string, perm os.FileMode) error {
+	if _, err := fs.realPath(path); err != nil {
+		return &os.PathError{Op: "mkdir", Path: path, Err: err}
+	}
+	return fs.BasePathFs.MkdirAll(path, perm)
+}
+
+func (fs *secureBasePathFs) Remove(name string) error {
+	if _, err := fs.realPath(name); err != nil {
+		return &os.PathError{Op: "remove", Path: name, Err: err}
+	}
+	return fs.BasePathFs.Remove(name)
+}
+
+func (fs *secureBasePathFs) RemoveAll(path string) error {
+	if _, err := fs.realPath(path); err != nil {
+		return &os.PathError{Op: "remove_all", Path: path, Err: err}
+	}
+	return fs.BasePathFs.RemoveAll(path)
+}
+
+func (fs *secureBasePathFs) Rename(oldname, newname string) error {
+	if _, err := fs.realPath(oldname); err != nil {
+		return &os.PathError{Op: "rename", Path: oldname, Err: err}
+	}
+	if _, err := fs.realPath(newname); err != nil {
+		return &os.PathError{Op: "rename", Path: newname, Err: err}
+	}
+	return fs.BasePathFs.Rename(oldname, newname)
+}
+
+func (fs *secureBasePathFs) Chmod(name string, mode os.FileMode) error {
+	if _, err := fs.realPath(name); err != nil {
+		return &os.PathError{Op: "chmod", Path: name, Err: err}
+	}
+	return fs.BasePathFs.Chmod(name, mode)
+}
+
+func (fs *secureBasePathFs) Chtimes(name string, atime, mtime time.Time) error {
+	if _, err := fs.realPath(name); err != nil {
+		return &os.PathError{Op: "chtimes", Path: name, Err: err}
+	}
+	return fs.BasePathFs.Chtimes(name, atime, mtime)
+}
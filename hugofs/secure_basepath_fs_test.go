@@ -0,0 +1,98 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hugofs
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSecureBasePathFs(t *testing.T) {
+	assert := require.New(t)
+
+	tmp, err := ioutil.TempDir("", "hugo-secure-basepath")
+	assert.NoError(err)
+	defer os.RemoveAll(tmp)
+
+	root := filepath.Join(tmp, "module")
+	outside := filepath.Join(tmp, "outside")
+	assert.NoError(os.MkdirAll(root, 0777))
+	assert.NoError(os.MkdirAll(outside, 0777))
+
+	assert.NoError(ioutil.WriteFile(filepath.Join(root, "safe.txt"), []byte("safe"), 0666))
+	assert.NoError(ioutil.WriteFile(filepath.Join(outside, "secret.txt"), []byte("secret"), 0666))
+	assert.NoError(os.Symlink(filepath.Join(outside, "secret.txt"), filepath.Join(root, "escape.txt")))
+
+	fs := NewSecureBasePathFs(afero.NewOsFs(), root)
+
+	f, err := fs.Open("safe.txt")
+	assert.NoError(err)
+	b, err := ioutil.ReadAll(f)
+	assert.NoError(err)
+	assert.Equal("safe", string(b))
+	f.Close()
+
+	_, err = fs.Open("escape.txt")
+	assert.Error(err)
+	assert.True(os.IsPermission(err))
+
+	_, err = fs.Stat("escape.txt")
+	assert.Error(err)
+	assert.True(os.IsPermission(err))
+}
+
+// The symlink-escape guard must cover every mutating method, not just
+// reads -- otherwise a symlink inside root could still be used to write to
+// or remove files outside it.
+func TestSecureBasePathFsMutatingMethods(t *testing.T) {
+	assert := require.New(t)
+
+	tmp, err := ioutil.TempDir("", "hugo-secure-basepath-mutate")
+	assert.NoError(err)
+	defer os.RemoveAll(tmp)
+
+	root := filepath.Join(tmp, "module")
+	outside := filepath.Join(tmp, "outside")
+	assert.NoError(os.MkdirAll(root, 0777))
+	assert.NoError(os.MkdirAll(outside, 0777))
+
+	assert.NoError(ioutil.WriteFile(filepath.Join(outside, "secret.txt"), []byte("secret"), 0666))
+	assert.NoError(os.Symlink(filepath.Join(outside, "secret.txt"), filepath.Join(root, "escape.txt")))
+	assert.NoError(os.Symlink(outside, filepath.Join(root, "escape-dir")))
+
+	fs := NewSecureBasePathFs(afero.NewOsFs(), root)
+
+	_, err = fs.Create("escape.txt")
+	assert.Error(err)
+	assert.True(os.IsPermission(err))
+
+	assert.Error(fs.Mkdir(filepath.Join("escape-dir", "newdir"), 0777))
+	assert.Error(fs.MkdirAll(filepath.Join("escape-dir", "a", "b"), 0777))
+	assert.Error(fs.Remove("escape.txt"))
+	assert.Error(fs.RemoveAll("escape-dir"))
+	assert.Error(fs.Rename("escape.txt", "renamed.txt"))
+	assert.Error(fs.Chmod("escape.txt", 0644))
+	assert.Error(fs.Chtimes("escape.txt", time.Now(), time.Now()))
+
+	// The escape attempt must not have reached the real file outside root.
+	b, err := ioutil.ReadFile(filepath.Join(outside, "secret.txt"))
+	assert.NoError(err)
+	assert.Equal("secret", string(b))
+}
@@ -28,6 +28,7 @@ import (
 	"github.com/gohugoio/hugo/config"
 
 	"github.com/gohugoio/hugo/hugofs"
+	"github.com/gohugoio/hugo/hugofs/glob"
 
 	"fmt"
 
@@ -110,6 +111,14 @@ type SourceFilesystem struct {
 	// Dirnames is absolute filenames to the directories in this filesystem.
 	Dirnames []string
 
+	// DirnameFilters, when non-nil, is paired index-for-index with Dirnames
+	// and mirrors the mount-level Includes/Excludes filter already applied
+	// to Fs. It lets Contains and MakePathRelative agree with Fs about which
+	// files are visible, so e.g. a watch event for an excluded file is
+	// dropped rather than triggering a rebuild. A nil entry matches
+	// everything.
+	DirnameFilters []*glob.FilenameFilter
+
 	// When syncing a source folder to the target (e.g. /public), this may
 	// be set to publish into a subfolder. This is used for static syncing
 	// in multihost mode.
@@ -211,14 +220,44 @@ func (s SourceFilesystems) MakeStaticPathRelative(filename string) string {
 // MakePathRelative creates a relative path from the given filename.
 // It will return an empty string if the filename is not a member of this filesystem.
 func (d *SourceFilesystem) MakePathRelative(filename string) string {
-	for _, currentPath := range d.Dirnames {
+	if d == nil {
+		return ""
+	}
+	for i, currentPath := range d.Dirnames {
 		if strings.HasPrefix(filename, currentPath) {
-			return strings.TrimPrefix(filename, currentPath)
+			rel := strings.TrimPrefix(filename, currentPath)
+			if !d.filterAllows(i, rel, d.isDir(filename)) {
+				return ""
+			}
+			return rel
 		}
 	}
 	return ""
 }
 
+// isDir reports whether filename is a directory in d's underlying source
+// filesystem. It returns false, rather than erroring, if that can't be
+// determined (e.g. filename no longer exists, as for a remove event).
+func (d *SourceFilesystem) isDir(filename string) bool {
+	if d.SourceFs == nil {
+		return false
+	}
+	fi, err := d.SourceFs.Stat(filename)
+	return err == nil && fi.IsDir()
+}
+
+// filterAllows reports whether rel, relative to the i'th Dirnames entry,
+// passes that directory's DirnameFilters entry, if any. isDir must reflect
+// whether rel itself names a directory: an Includes-only filter still lets
+// a non-matching directory through so traversal can reach matching files
+// beneath it, see glob.FilenameFilter.Match.
+func (d *SourceFilesystem) filterAllows(i int, rel string, isDir bool) bool {
+	if i >= len(d.DirnameFilters) || d.DirnameFilters[i] == nil {
+		return true
+	}
+	return d.DirnameFilters[i].Match(filepath.ToSlash(rel), isDir)
+}
+
 func (d *SourceFilesystem) RealFilename(rel string) string {
 	fi, err := d.Fs.Stat(rel)
 	if err != nil {
@@ -232,10 +271,15 @@ func (d *SourceFilesystem) RealFilename(rel string) string {
 }
 
 // Contains returns whether the given filename is a member of the current filesystem.
+// A nil SourceFilesystem, e.g. a BaseFs source type that wasn't configured
+// for the current build, never contains anything.
 func (d *SourceFilesystem) Contains(filename string) bool {
-	for _, dir := range d.Dirnames {
+	if d == nil {
+		return false
+	}
+	for i, dir := range d.Dirnames {
 		if strings.HasPrefix(filename, dir) {
-			return true
+			return d.filterAllows(i, strings.TrimPrefix(filename, dir), d.isDir(filename))
 		}
 	}
 	return false
@@ -503,12 +547,13 @@ func (b *sourceFilesystemsBuilder) createRootMappingFs(dirKey, themeFolder strin
 		return nil, fmt.Errorf("config %q not set", dirKey)
 	}
 
-	var fromTo []string
+	var rms []hugofs.RootMapping
 	to := b.p.AbsPathify(projectDir)
 
 	if b.existsInSource(to) {
 		s.Dirnames = []string{to}
-		fromTo = []string{projectVirtualFolder, to}
+		s.DirnameFilters = []*glob.FilenameFilter{nil}
+		rms = append(rms, hugofs.RootMapping{From: projectVirtualFolder, To: to})
 	}
 
 	for _, module := range b.p.AllModules {
@@ -528,23 +573,29 @@ func (b *sourceFilesystemsBuilder) createRootMappingFs(dirKey, themeFolder strin
 			}
 			to := filepath.Join(module.Dir(), mount.Source)
 			if b.existsInSource(to) {
+				filter, err := glob.NewFilenameFilter(mount.Includes, mount.Excludes)
+				if err != nil {
+					return nil, err
+				}
+
 				// TODO(bep) mod Dirnames is used for watching, maybe
 				// not applicable to all.
 
 				s.Dirnames = append(s.Dirnames, to)
+				s.DirnameFilters = append(s.DirnameFilters, filter)
 				from := filepath.Join(module.Path(), strings.TrimPrefix(mount.Target, themeFolder))
-				fromTo = append(fromTo, from, to)
+				rms = append(rms, hugofs.RootMapping{From: from, To: to, Filter: filter})
 			}
 
 		}
 	}
 
-	if len(fromTo) == 0 {
+	if len(rms) == 0 {
 		s.Fs = hugofs.NoOpFs
 		return s, nil
 	}
 
-	fs, err := hugofs.NewRootMappingFsFromFromTo(b.p.Fs.Source, fromTo...)
+	fs, err := hugofs.NewRootMappingFs(b.p.Fs.Source, rms...)
 	if err != nil {
 		return nil, err
 	}
@@ -785,16 +836,35 @@ func (b *sourceFilesystemsBuilder) isContentMount(mnt modules.Mount) bool {
 	return strings.HasPrefix(mnt.Target, "content")
 }
 
+// isLangAwareMount reports whether mnt targets one of the component
+// folders whose files can be scoped to a single content language via
+// Mount.Lang: content, data and i18n.
+func (b *sourceFilesystemsBuilder) isLangAwareMount(mnt modules.Mount) bool {
+	return b.isContentMount(mnt) || strings.HasPrefix(mnt.Target, "data") || strings.HasPrefix(mnt.Target, "i18n")
+}
+
 func (b *sourceFilesystemsBuilder) createModFs(source afero.Fs, mod modules.Module) (*hugofs.RootMappingFs, bool, error) {
 
 	var fromTo []hugofs.RootMapping
 	var hasContentMount bool
 
 	for _, mount := range mod.Mounts() {
-		fmt.Println(">>> SOURCE/TARGET", mount.Source, mount.Target)
+		filter, err := glob.NewFilenameFilter(mount.Includes, mount.Excludes)
+		if err != nil {
+			return nil, hasContentMount, err
+		}
+
 		rm := hugofs.RootMapping{
-			From: mount.Target,
-			To:   mount.Source,
+			From:   mount.Target,
+			Filter: filter,
+		}
+
+		if modules.IsRemoteMountSource(mount.Source) {
+			// Already fetched and cached by the modules package; mount.Fs()
+			// is rooted exactly at the cached copy.
+			rm.Fs = mount.Fs()
+		} else {
+			rm.To = mount.Source
 		}
 
 		if b.isContentMount(mount) {
@@ -804,6 +874,8 @@ func (b *sourceFilesystemsBuilder) createModFs(source afero.Fs, mod modules.Modu
 				lang = b.p.DefaultContentLanguage
 			}
 			rm.Lang = lang
+		} else if mount.Lang != "" && b.isLangAwareMount(mount) {
+			rm.Lang = mount.Lang
 		}
 		fromTo = append(fromTo, rm)
 	}
@@ -831,37 +903,44 @@ func printFs(fs afero.Fs, path string, w io.Writer) {
 
 const contentBase = "content"
 
+// createThemeOverlayFs stacks one filesystem per module in mods, highest
+// precedence first, into a single hugofs.FallbackFs -- giving two modules
+// that both mount the same Target (e.g. "assets/scss") theme-inheritance
+// semantics with per-file granularity, rather than one module's mount
+// wholesale shadowing the other's. FallbackFs, unlike hugofs.OverlayFs,
+// decorates every os.FileInfo it hands back as a hugofs.FileMetaInfo, so a
+// caller can still read the real filename from Meta().Filename() no matter
+// which module's mount actually backed it.
 func (b *sourceFilesystemsBuilder) createThemeOverlayFs(source afero.Fs, mods modules.Modules) (afero.Fs, []hugofs.LangFsProvider, error) {
 	if len(mods) == 0 {
 		return hugofs.NoOpFs, nil, nil
 	}
 
-	var contentFss []hugofs.LangFsProvider
+	var (
+		contentFss []hugofs.LangFsProvider
+		layers     []afero.Fs
+	)
 
-	base, hasContent, err := b.createModFs(source, mods[0])
-	if err != nil {
-		return nil, nil, err
-	}
-	if hasContent {
-		ldirs, err := base.Dirs(contentBase)
+	for _, mod := range mods {
+		base, hasContent, err := b.createModFs(source, mod)
 		if err != nil {
 			return nil, nil, err
 		}
-		contentFss = append(contentFss, hugofs.ToLangFsProviders(ldirs)...)
-	}
-
-	if len(mods) == 1 {
-		return base, contentFss, nil
+		if hasContent {
+			ldirs, err := base.Dirs(contentBase)
+			if err != nil {
+				return nil, nil, err
+			}
+			contentFss = append(contentFss, hugofs.ToLangFsProviders(ldirs)...)
+		}
+		layers = append(layers, base)
 	}
 
-	overlay, overlayContentFss, err := b.createThemeOverlayFs(source, mods[1:])
-	if err != nil {
-		return nil, nil, err
+	if len(layers) == 1 {
+		return layers[0], contentFss, nil
 	}
 
-	contentFss = append(contentFss, overlayContentFss...)
-
-	return afero.NewCopyOnWriteFs(base, overlay), contentFss, nil
+	return hugofs.NewFallbackFs(layers...), contentFss, nil
 }
 
 // TODO(bep) mod remove
@@ -0,0 +1,106 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filesystems
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/gohugoio/hugo/config"
+	"github.com/gohugoio/hugo/hugofs/glob"
+	"github.com/gohugoio/hugo/modules"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeModule is a minimal modules.Module, enough to drive
+// createThemeOverlayFs without a full paths.Paths/module graph.
+type fakeModule struct {
+	dir    string
+	path   string
+	mounts []modules.Mount
+}
+
+func (m *fakeModule) Cfg() config.Provider    { return nil }
+func (m *fakeModule) ConfigFilename() string  { return "" }
+func (m *fakeModule) Dir() string             { return m.dir }
+func (m *fakeModule) IsGoMod() bool           { return false }
+func (m *fakeModule) Owner() modules.Module   { return nil }
+func (m *fakeModule) Replace() modules.Module { return nil }
+func (m *fakeModule) Mounts() []modules.Mount { return m.mounts }
+func (m *fakeModule) Path() string            { return m.path }
+func (m *fakeModule) Vendor() bool            { return false }
+func (m *fakeModule) Version() string         { return "" }
+
+// The project's own mount must win over a theme's mount on a colliding
+// path -- createThemeOverlayFs stacks one hugofs.FallbackFs layer per
+// module, highest precedence first, and it's easy to get that stacking
+// backwards.
+func TestCreateThemeOverlayFsProjectWinsOverTheme(t *testing.T) {
+	assert := require.New(t)
+
+	fs := afero.NewMemMapFs()
+	assert.NoError(afero.WriteFile(fs, "myproject/layouts/index.html", []byte("project"), 0755))
+	assert.NoError(afero.WriteFile(fs, "mytheme/layouts/index.html", []byte("theme"), 0755))
+
+	project := &fakeModule{
+		dir:    "myproject",
+		path:   "myproject",
+		mounts: []modules.Mount{{Source: "layouts", Target: "layouts"}},
+	}
+	theme := &fakeModule{
+		dir:    "mytheme",
+		path:   "github.com/bep/mytheme",
+		mounts: []modules.Mount{{Source: "layouts", Target: "layouts"}},
+	}
+
+	b := &sourceFilesystemsBuilder{result: &SourceFilesystems{}}
+
+	// Highest precedence first, same order createThemesOverlayFs2 passes in.
+	overlay, _, err := b.createThemeOverlayFs(fs, modules.Modules{project, theme})
+	assert.NoError(err)
+
+	f, err := overlay.Open("layouts/index.html")
+	assert.NoError(err)
+	defer f.Close()
+
+	b2, err := ioutil.ReadAll(f)
+	assert.NoError(err)
+	assert.Equal("project", string(b2))
+}
+
+// Contains must let a directory through an Includes-only filter even when
+// the directory's own name doesn't match, since it may still hold matching
+// files beneath it -- the real isDir, not a hardcoded false, is what makes
+// glob.FilenameFilter.Match apply its dedicated directory-traversal branch.
+func TestSourceFilesystemContainsDirTraversal(t *testing.T) {
+	assert := require.New(t)
+
+	fs := afero.NewMemMapFs()
+	assert.NoError(afero.WriteFile(fs, filepath.Join("layouts", "partials", "header.html"), []byte("x"), 0755))
+
+	filter, err := glob.NewFilenameFilter([]string{"**.html"}, nil)
+	assert.NoError(err)
+
+	d := &SourceFilesystem{
+		SourceFs:       fs,
+		Dirnames:       []string{"layouts" + filePathSeparator},
+		DirnameFilters: []*glob.FilenameFilter{filter},
+	}
+
+	assert.True(d.Contains(filepath.Join("layouts", "partials")))
+	assert.False(d.Contains(filepath.Join("layouts", "partials", "notes.txt")))
+	assert.True(d.Contains(filepath.Join("layouts", "partials", "header.html")))
+}
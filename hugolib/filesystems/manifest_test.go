@@ -0,0 +1,74 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filesystems
+
+import (
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gohugoio/hugo/hugofs"
+)
+
+func metaFor(assert *require.Assertions, name, content string) hugofs.FileMeta {
+	fs := afero.NewMemMapFs()
+	assert.NoError(afero.WriteFile(fs, name, []byte(content), 0755))
+
+	bfs := hugofs.NewBasePathRealFilenameFs(afero.NewBasePathFs(fs, "/").(*afero.BasePathFs))
+	fi, err := bfs.Stat(name)
+	assert.NoError(err)
+
+	return fi.(hugofs.FileMetaInfo).Meta()
+}
+
+func TestBuildManifestChangedAndRecord(t *testing.T) {
+	assert := require.New(t)
+
+	meta := metaFor(assert, "post.md", "first draft")
+
+	m := NewBuildManifest()
+
+	changed, err := m.Changed("content/post.md", meta)
+	assert.NoError(err)
+	assert.True(changed, "unseen path should be reported as changed")
+
+	assert.NoError(m.Record("content/post.md", meta))
+
+	changed, err = m.Changed("content/post.md", meta)
+	assert.NoError(err)
+	assert.False(changed, "unmodified path should be reported as unchanged")
+}
+
+func TestBuildManifestSaveLoad(t *testing.T) {
+	assert := require.New(t)
+
+	meta := metaFor(assert, "post.md", "first draft")
+
+	m := NewBuildManifest()
+	assert.NoError(m.Record("content/post.md", meta))
+
+	fs := afero.NewMemMapFs()
+	assert.NoError(m.Save(fs, "manifest.json"))
+
+	loaded, err := LoadBuildManifest(fs, "manifest.json")
+	assert.NoError(err)
+	changed, err := loaded.Changed("content/post.md", meta)
+	assert.NoError(err)
+	assert.False(changed)
+
+	empty, err := LoadBuildManifest(fs, "does-not-exist.json")
+	assert.NoError(err)
+	assert.Empty(empty.Hashes)
+}
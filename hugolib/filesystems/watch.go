@@ -0,0 +1,221 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filesystems
+
+import (
+	"context"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/gohugoio/hugo/hugofs"
+	"github.com/pkg/errors"
+)
+
+// EventKind classifies the kind of change an FsEvent represents.
+type EventKind int
+
+const (
+	EventCreate EventKind = iota
+	EventWrite
+	EventRemove
+	EventRename
+)
+
+// SourceType identifies which of BaseFs's source filesystems an FsEvent
+// belongs to.
+type SourceType string
+
+const (
+	SourceContent    SourceType = "content"
+	SourceData       SourceType = "data"
+	SourceI18n       SourceType = "i18n"
+	SourceLayouts    SourceType = "layouts"
+	SourceArchetypes SourceType = "archetypes"
+	SourceAssets     SourceType = "assets"
+	SourceStatic     SourceType = "static"
+	SourceUnknown    SourceType = ""
+)
+
+// FsEvent is a normalized, classified filesystem change event as produced by
+// BaseFs.Watch.
+type FsEvent struct {
+	Kind EventKind
+
+	// RelPath is the path of the changed file relative to the source
+	// filesystem it belongs to, e.g. "posts/my-post.md" for a content file.
+	RelPath string
+
+	// Lang is the content language the changed file belongs to, if any.
+	Lang string
+
+	SourceType SourceType
+}
+
+// watchDebounce is how long Watch waits, after the last observed event in a
+// burst, before classifying and emitting the lot. Editors and syncing tools
+// routinely produce several raw fsnotify events per logical save.
+const watchDebounce = 300 * time.Millisecond
+
+// Watch starts watching each of the Dirnames of Content, Data, I18n, Layouts,
+// Archetypes, Assets and Static -- including theme/module and remote-cache
+// dirs -- and returns a channel of classified, debounced FsEvents. Each
+// directory gets one non-recursive fsnotify watch; changes inside its
+// subdirectories are not reported. This
+// centralizes the watching and classification logic that has traditionally
+// lived, duplicated, in the server command. The returned channel is closed
+// once ctx is done.
+func (b *BaseFs) Watch(ctx context.Context) (<-chan FsEvent, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create filesystem watcher")
+	}
+
+	for _, dir := range b.dirnames() {
+		if err := watcher.Add(dir); err != nil {
+			watcher.Close()
+			return nil, errors.Wrapf(err, "failed to watch %q", dir)
+		}
+	}
+
+	events := make(chan FsEvent)
+
+	go b.debounce(ctx, watcher, events)
+
+	return events, nil
+}
+
+// dirnames returns the union of Dirnames across all of the source
+// filesystems, used to seed the fsnotify.Watcher in Watch.
+func (b *BaseFs) dirnames() []string {
+	var dirs []string
+
+	add := func(fs *SourceFilesystem) {
+		if fs == nil {
+			return
+		}
+		dirs = append(dirs, fs.Dirnames...)
+	}
+
+	add(b.Content)
+	add(b.Data)
+	add(b.I18n)
+	add(b.Layouts)
+	add(b.Archetypes)
+	add(b.Assets)
+	for _, fs := range b.Static {
+		add(fs)
+	}
+
+	return dirs
+}
+
+func (b *BaseFs) debounce(ctx context.Context, watcher *fsnotify.Watcher, events chan<- FsEvent) {
+	defer watcher.Close()
+	defer close(events)
+
+	pending := make(map[string]fsnotify.Event)
+	var timer *time.Timer
+	var timerC <-chan time.Time
+
+	flush := func() {
+		for _, evt := range pending {
+			events <- b.classify(evt)
+		}
+		pending = make(map[string]fsnotify.Event)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case evt, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			pending[evt.Name] = evt
+			if timer == nil {
+				timer = time.NewTimer(watchDebounce)
+			} else {
+				timer.Reset(watchDebounce)
+			}
+			timerC = timer.C
+		case <-timerC:
+			flush()
+			timerC = nil
+		case _, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+		}
+	}
+}
+
+// classify maps a raw fsnotify.Event to a normalized FsEvent, resolving
+// which source filesystem (and, for content/static, which language) the
+// changed file belongs to.
+func (b *BaseFs) classify(evt fsnotify.Event) FsEvent {
+	out := FsEvent{RelPath: evt.Name}
+
+	switch {
+	case evt.Op&fsnotify.Create != 0:
+		out.Kind = EventCreate
+	case evt.Op&fsnotify.Remove != 0:
+		out.Kind = EventRemove
+	case evt.Op&fsnotify.Rename != 0:
+		out.Kind = EventRename
+	default:
+		out.Kind = EventWrite
+	}
+
+	if fs, t := b.Content, SourceContent; fs.Contains(evt.Name) {
+		out.SourceType = t
+		out.RelPath = fs.MakePathRelative(evt.Name)
+		if fs.Fs != nil {
+			if fi, err := fs.Fs.Stat(out.RelPath); err == nil {
+				if fim, ok := fi.(hugofs.FileMetaInfo); ok {
+					out.Lang = fim.Meta().Lang()
+				}
+			}
+		}
+		return out
+	}
+
+	for _, c := range []struct {
+		fs *SourceFilesystem
+		t  SourceType
+	}{
+		{b.Data, SourceData},
+		{b.I18n, SourceI18n},
+		{b.Layouts, SourceLayouts},
+		{b.Archetypes, SourceArchetypes},
+		{b.Assets, SourceAssets},
+	} {
+		if c.fs.Contains(evt.Name) {
+			out.SourceType = c.t
+			out.RelPath = c.fs.MakePathRelative(evt.Name)
+			return out
+		}
+	}
+
+	for lang, fs := range b.Static {
+		if fs.Contains(evt.Name) {
+			out.SourceType = SourceStatic
+			out.RelPath = fs.MakePathRelative(evt.Name)
+			out.Lang = lang
+			return out
+		}
+	}
+
+	return out
+}
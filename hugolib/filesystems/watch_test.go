@@ -0,0 +1,50 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package filesystems
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBaseFsClassify(t *testing.T) {
+	assert := require.New(t)
+
+	contentDir := filepath.FromSlash("/my-project/content/")
+	dataDir := filepath.FromSlash("/my-project/data/")
+
+	b := &BaseFs{
+		SourceFilesystems: &SourceFilesystems{
+			Content: &SourceFilesystem{Dirnames: []string{contentDir}},
+			Data:    &SourceFilesystem{Dirnames: []string{dataDir}},
+		},
+	}
+
+	evt := b.classify(fsnotify.Event{Name: filepath.Join(contentDir, "post.md"), Op: fsnotify.Write})
+	assert.Equal(SourceContent, evt.SourceType)
+	assert.Equal(EventWrite, evt.Kind)
+	assert.Equal("post.md", evt.RelPath)
+
+	evt = b.classify(fsnotify.Event{Name: filepath.Join(dataDir, "colors.json"), Op: fsnotify.Create})
+	assert.Equal(SourceData, evt.SourceType)
+	assert.Equal(EventCreate, evt.Kind)
+	assert.Equal("colors.json", evt.RelPath)
+
+	evt = b.classify(fsnotify.Event{Name: filepath.FromSlash("/elsewhere/unknown.txt"), Op: fsnotify.Remove})
+	assert.Equal(SourceUnknown, evt.SourceType)
+	assert.Equal(EventRemove, evt.Kind)
+}
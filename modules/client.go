@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"net/http"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -30,6 +31,7 @@ import (
 	"github.com/rogpeppe/go-internal/module"
 
 	"github.com/gohugoio/hugo/common/hugio"
+	"github.com/gohugoio/hugo/hugofs"
 
 	"github.com/pkg/errors"
 	"github.com/spf13/afero"
@@ -75,6 +77,16 @@ type ClientConfig struct {
 	ThemesDir    string // Absolute directory path
 	ModProxy     string
 	ModuleConfig Config
+
+	// WorkspaceFile, if set, is the path to a hugo.work file enabling
+	// workspace mode, see LoadWorkspace. A relative path is resolved
+	// against WorkingDir.
+	WorkspaceFile string
+
+	// Verify, if enabled, makes Collect also run Verify, failing fast if
+	// any module vendored below _vendor has drifted from its recorded
+	// hash in modules.txt.
+	Verify bool
 }
 
 // TODO(bep) mod document modProxy config + HUGO_MODPROXY
@@ -104,6 +116,14 @@ func NewClient(cfg ClientConfig) *Client {
 	env := os.Environ()
 	setEnvVars(&env, "PWD", cfg.WorkingDir, "GOPROXY", getGoProxy())
 
+	var workspaceFilename string
+	if cfg.WorkspaceFile != "" {
+		workspaceFilename = cfg.WorkspaceFile
+		if !filepath.IsAbs(workspaceFilename) {
+			workspaceFilename = filepath.Join(cfg.WorkingDir, workspaceFilename)
+		}
+	}
+
 	return &Client{
 		fs:                fs,
 		ignoreVendor:      cfg.IgnoreVendor,
@@ -111,6 +131,8 @@ func NewClient(cfg ClientConfig) *Client {
 		themesDir:         cfg.ThemesDir,
 		moduleConfig:      cfg.ModuleConfig,
 		environ:           env,
+		workspaceFilename: workspaceFilename,
+		verify:            cfg.Verify,
 		GoModulesFilename: goModFilename}
 }
 
@@ -130,6 +152,12 @@ type Client struct {
 	// The top level module config
 	moduleConfig Config
 
+	// Absolute path to a hugo.work file, set if workspace mode is enabled.
+	workspaceFilename string
+
+	// If set, Collect also runs Verify before returning.
+	verify bool
+
 	// Environment variables used in "go get" etc.
 	environ []string
 
@@ -142,11 +170,20 @@ type Client struct {
 	// so we can give an instructional error at the end if module/theme
 	// resolution fails.
 	goBinaryStatus goBinaryStatus
+
+	// Set by Outdated and Graph for the duration of their Collect call, so
+	// listGoMods knows to also resolve Update/Deprecated info via "go list
+	// -u". Regular builds leave this false: "-u" queries the module proxy,
+	// which a build otherwise has no reason to touch.
+	withUpdates bool
 }
 
 // TODO(bep) mod probably filter this against imports? Also check replace.
 // TODO(bep) merge with _vendor + /theme
 func (m *Client) Graph(w io.Writer) error {
+	m.withUpdates = true
+	defer func() { m.withUpdates = false }()
+
 	mc, err := m.Collect()
 	if err != nil {
 		return err
@@ -155,7 +192,10 @@ func (m *Client) Graph(w io.Writer) error {
 		dep := pathVersion(module.Owner()) + " " + pathVersion(module)
 		if replace := module.Replace(); replace != nil {
 			dep += " => " + replace.Dir()
+		} else if isWorkspaceModule(module) {
+			dep += " => " + module.Dir() + " (workspace)"
 		}
+		dep += outdatedSuffix(module)
 		fmt.Fprintln(w, dep)
 
 	}
@@ -163,6 +203,207 @@ func (m *Client) Graph(w io.Writer) error {
 	return nil
 }
 
+// ModulePath is one hop in the import chain returned by Client.Why: a
+// single module's path, rendered the same way as Graph's edges (with its
+// version, and a "+vendor" suffix when resolved from _vendor).
+type ModulePath string
+
+// Why returns the shortest import chain from a main module (the project
+// itself, or a hugo.work member) to the module at path, computed by BFS
+// over the same owner/dependency edges Graph prints as a flat list. The
+// first entry is always a main module; the last is path itself. It
+// returns a nil slice, with no error, if path is not part of the
+// dependency graph.
+func (m *Client) Why(path string) ([]ModulePath, error) {
+	mc, err := m.Collect()
+	if err != nil {
+		return nil, err
+	}
+
+	children := make(map[Module][]Module)
+	roots := make(map[Module]bool)
+
+	for _, module := range mc.Modules {
+		owner := module.Owner()
+		children[owner] = append(children[owner], module)
+		if owner != nil && owner.Owner() == nil {
+			roots[owner] = true
+		}
+	}
+
+	type queued struct {
+		module Module
+		chain  []ModulePath
+	}
+
+	var queue []queued
+	for root := range roots {
+		chain := []ModulePath{ModulePath(pathVersion(root))}
+		if root.Path() == path {
+			return chain, nil
+		}
+		queue = append(queue, queued{root, chain})
+	}
+
+	for len(queue) > 0 {
+		item := queue[0]
+		queue = queue[1:]
+
+		for _, child := range children[item.module] {
+			chain := append(append([]ModulePath{}, item.chain...), ModulePath(pathVersion(child)))
+			if child.Path() == path {
+				return chain, nil
+			}
+			queue = append(queue, queued{child, chain})
+		}
+	}
+
+	return nil, nil
+}
+
+// Outdated writes, one per line, every Go module dependency that has a
+// newer version available upstream (per "go list -m -u") or has been
+// marked deprecated by its maintainer, split into direct and indirect
+// requirements of the main module(s).
+func (m *Client) Outdated(w io.Writer) error {
+	m.withUpdates = true
+	defer func() { m.withUpdates = false }()
+
+	mc, err := m.Collect()
+	if err != nil {
+		return err
+	}
+
+	var direct, indirect []string
+
+	for _, module := range mc.Modules {
+		gomod := goModuleStatus(module)
+		if gomod == nil || (gomod.Update == nil && gomod.Deprecated == "") {
+			continue
+		}
+
+		line := pathVersion(module) + outdatedSuffix(module)
+
+		if gomod.Indirect {
+			indirect = append(indirect, line)
+		} else {
+			direct = append(direct, line)
+		}
+	}
+
+	if len(direct) > 0 {
+		fmt.Fprintln(w, "Direct:")
+		for _, line := range direct {
+			fmt.Fprintln(w, "  "+line)
+		}
+	}
+
+	if len(indirect) > 0 {
+		fmt.Fprintln(w, "Indirect:")
+		for _, line := range indirect {
+			fmt.Fprintln(w, "  "+line)
+		}
+	}
+
+	return nil
+}
+
+// goModuleStatus returns the raw "go list -m -u" info for m, or nil if m
+// isn't backed by a Go module.
+func goModuleStatus(m Module) *goModule {
+	ma, ok := m.(*moduleAdapter)
+	if !ok {
+		return nil
+	}
+	return ma.gomod
+}
+
+// outdatedSuffix renders m's available-update and deprecation status, if
+// any, as a suffix for a Graph or Outdated line, e.g.
+// " [v1.2.3 available] [deprecated: use github.com/foo/bar instead]".
+func outdatedSuffix(m Module) string {
+	gomod := goModuleStatus(m)
+	if gomod == nil {
+		return ""
+	}
+
+	var s string
+	if gomod.Update != nil {
+		s += fmt.Sprintf(" [%s available]", gomod.Update.Version)
+	}
+	if gomod.Deprecated != "" {
+		s += fmt.Sprintf(" [deprecated: %s]", gomod.Deprecated)
+	}
+
+	return s
+}
+
+// BaseFs returns a read-only hugofs.FallbackFs stacking, in precedence
+// order, the project's own component dir and every module's mounts that
+// target component (e.g. "layouts", "assets", "data"). A lookup for a file
+// such as "partials/header.html" therefore transparently walks project ->
+// module A -> module B -> ..., the same order used when building the
+// site's content, layouts, etc. Each layer is a
+// hugofs.BasePathRealFilenameFs rather than a bare afero.BasePathFs, so
+// when two Imports' Mounts both target component (e.g. two themes both
+// providing "assets/scss"), FallbackFs still lets a caller recover which
+// one's Mount actually backed a given file. Writes are not supported; use
+// the project dir directly for that.
+//
+// Each matching mount's Source directory is layered at the root of
+// component: the part of Target below component (e.g. "bootstrap/scss" in
+// "assets/bootstrap/scss") is not prepended to its files' paths, and the
+// mount's Includes, Excludes and Lang are not applied.
+func (m *Client) BaseFs(component string) (afero.Fs, error) {
+	mc, err := m.Collect()
+	if err != nil {
+		return nil, err
+	}
+
+	var layers []afero.Fs
+
+	addLayer := func(dir string, mounts []Mount) {
+		for _, mnt := range mounts {
+			if mnt.Target != component && !strings.HasPrefix(mnt.Target, component+fileSeparator) {
+				continue
+			}
+			sourceDir := filepath.Join(dir, mnt.Source)
+			if found, _ := afero.Exists(m.fs, sourceDir); !found {
+				continue
+			}
+			base := afero.NewBasePathFs(m.fs, sourceDir).(*afero.BasePathFs)
+			layers = append(layers, hugofs.NewBasePathRealFilenameFs(base))
+		}
+	}
+
+	// The project itself always has the highest precedence.
+	addLayer(m.workingDir, []Mount{{Source: component, Target: component}})
+
+	for _, mod := range mc.Modules {
+		addLayer(mod.Dir(), mod.Mounts())
+	}
+
+	if len(layers) == 0 {
+		return hugofs.NoOpFs, nil
+	}
+
+	return afero.NewReadOnlyFs(hugofs.NewFallbackFs(layers...)), nil
+}
+
+// HTTPFileSystem exposes the composed module overlay for component (typically
+// "static" or "assets") as a http.FileSystem, serving the same layers, in the
+// same precedence order, as BaseFs. This lets the
+// server command mount e.g. "/module-assets/" for debugging, and lets
+// third-party tools embed module resources without reimplementing the
+// merge/precedence logic in BaseFs. Like BaseFs, it never allows writes.
+func (m *Client) HTTPFileSystem(component string) (http.FileSystem, error) {
+	fs, err := m.BaseFs(component)
+	if err != nil {
+		return nil, err
+	}
+	return afero.NewHttpFs(fs).Dir("/"), nil
+}
+
 // Tidy can be used to remove unused dependencies from go.mod and go.sum.
 func (m *Client) Tidy() error {
 	tc, err := m.Collect()
@@ -235,7 +476,8 @@ func (m *Client) IsProbablyModule(path string) bool {
 // Unlike Go, we support it for any level.
 // We, by defaults, use the /_vendor folder first, if found. To disable,
 // run with
-//    hugo --ignoreVendor
+//
+//	hugo --ignoreVendor
 //
 // Given a module tree, Hugo will pick the first module for a given path,
 // meaning that if the top-level module is vendored, that will be the full
@@ -273,6 +515,7 @@ func (c *Client) Vendor() error {
 		fmt.Fprintln(&modulesContent, "# "+t.Path()+" "+t.Version())
 
 		dir := t.Dir()
+		targetDir := filepath.Join(vendorDir, t.Path())
 
 		shouldCopy := func(filename string) bool {
 			//base := filepath.Base(strings.TrimPrefix(filename, dir))
@@ -281,9 +524,15 @@ func (c *Client) Vendor() error {
 			return true // base != "_vendor" //dirnames[base]
 		}
 
-		if err := hugio.CopyDir(c.fs, dir, filepath.Join(vendorDir, t.Path()), shouldCopy); err != nil {
+		if err := hugio.CopyDir(c.fs, dir, targetDir, shouldCopy); err != nil {
 			return errors.Wrap(err, "failed to copy module to vendor dir")
 		}
+
+		hash, err := hashDir(c.fs, targetDir)
+		if err != nil {
+			return errors.Wrap(err, "failed to hash vendored module")
+		}
+		fmt.Fprintln(&modulesContent, "## "+hash)
 	}
 
 	if modulesContent.Len() > 0 {
@@ -320,8 +569,16 @@ func (m *Client) listGoMods() (goModules, error) {
 		return nil, errors.Wrap(err, "failed to download modules")
 	}
 
+	args := []string{"list", "-m", "-json", "all"}
+	if m.withUpdates {
+		// -u also resolves Update and Deprecated for every listed module,
+		// but requires querying the module proxy, so it's opt-in -- see
+		// Outdated and Graph, the only callers that need it.
+		args = []string{"list", "-m", "-u", "-json", "all"}
+	}
+
 	b := &bytes.Buffer{}
-	err = m.runGo(context.Background(), b, "list", "-m", "-json", "all")
+	err = m.runGo(context.Background(), b, args...)
 	if err != nil {
 		return nil, errors.Wrap(err, "failed to list modules")
 	}
@@ -493,17 +750,18 @@ type ModuleError struct {
 type goBinaryStatus int
 
 type goModule struct {
-	Path     string       // module path
-	Version  string       // module version
-	Versions []string     // available module versions (with -versions)
-	Replace  *goModule    // replaced by this module
-	Time     *time.Time   // time version was created
-	Update   *goModule    // available update, if any (with -u)
-	Main     bool         // is this the main module?
-	Indirect bool         // is this module only an indirect dependency of main module?
-	Dir      string       // directory holding files for this module, if any
-	GoMod    string       // path to go.mod file for this module, if any
-	Error    *ModuleError // error loading module
+	Path       string       // module path
+	Version    string       // module version
+	Versions   []string     // available module versions (with -versions)
+	Replace    *goModule    // replaced by this module
+	Time       *time.Time   // time version was created
+	Update     *goModule    // available update, if any (with -u)
+	Main       bool         // is this the main module?
+	Indirect   bool         // is this module only an indirect dependency of main module?
+	Dir        string       // directory holding files for this module, if any
+	GoMod      string       // path to go.mod file for this module, if any
+	Error      *ModuleError // error loading module
+	Deprecated string       // deprecation message, if any (with -u)
 }
 
 type goModules []*goModule
@@ -560,6 +818,13 @@ func getGoProxy() string {
 	return "direct"
 }
 
+// isWorkspaceModule reports whether m was provided by a hugo.work "use"
+// directive rather than resolved as a regular import.
+func isWorkspaceModule(m Module) bool {
+	ma, ok := m.(*moduleAdapter)
+	return ok && ma.workspace
+}
+
 func pathVersion(m Module) string {
 	versionStr := m.Version()
 	if m.Vendor() {
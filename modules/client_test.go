@@ -64,6 +64,19 @@ github.com/gohugoio/hugoTestModules1_darwin/modh2_2@v1.4.0 github.com/gohugoio/h
 
 	assert.Equal(expect, graphb.String())
 
+	// Test Why
+	why, err := client.Why("github.com/gohugoio/hugoTestModules1_darwin/modh2_2_2")
+	assert.NoError(err)
+	assert.Equal([]ModulePath{
+		ModulePath("github.com/gohugoio/tests/hugo-modules-basic-test"),
+		ModulePath("github.com/gohugoio/hugoTestModules1_darwin/modh2_2@v1.4.0"),
+		ModulePath("github.com/gohugoio/hugoTestModules1_darwin/modh2_2_2@v1.3.0"),
+	}, why)
+
+	whyMissing, err := client.Why("github.com/not/a/dependency")
+	assert.NoError(err)
+	assert.Nil(whyMissing)
+
 	// Test Vendor
 	assert.NoError(client.Vendor())
 	graphb.Reset()
@@ -92,6 +105,25 @@ github.com/gohugoio/tests/hugo-modules-basic-test github.com/gohugoio/hugoTestMo
 
 }
 
+func TestOutdatedSuffix(t *testing.T) {
+	assert := require.New(t)
+
+	plain := &moduleAdapter{path: "github.com/foo/plain"}
+	assert.Equal("", outdatedSuffix(plain))
+
+	withUpdate := &moduleAdapter{
+		path:  "github.com/foo/stale",
+		gomod: &goModule{Update: &goModule{Version: "v1.2.3"}},
+	}
+	assert.Equal(" [v1.2.3 available]", outdatedSuffix(withUpdate))
+
+	deprecated := &moduleAdapter{
+		path:  "github.com/foo/abandoned",
+		gomod: &goModule{Deprecated: "use github.com/foo/new instead"},
+	}
+	assert.Equal(" [deprecated: use github.com/foo/new instead]", outdatedSuffix(deprecated))
+}
+
 func TestSetEnvVars(t *testing.T) {
 	t.Parallel()
 	assert := require.New(t)
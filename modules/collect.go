@@ -30,7 +30,7 @@ import (
 const vendorModulesFilename = "modules.txt"
 
 func (h *Client) Collect() (ModulesConfig, error) {
-	if len(h.moduleConfig.Imports) == 0 {
+	if len(h.moduleConfig.Imports) == 0 && h.workspaceFilename == "" {
 		return ModulesConfig{}, nil
 	}
 
@@ -42,9 +42,20 @@ func (h *Client) Collect() (ModulesConfig, error) {
 		return ModulesConfig{}, err
 	}
 
+	if err := h.lockGoModules(c.modules); err != nil {
+		return ModulesConfig{}, err
+	}
+
+	if h.verify {
+		if err := h.Verify(); err != nil {
+			return ModulesConfig{}, err
+		}
+	}
+
 	return ModulesConfig{
 		Modules:           c.modules,
 		GoModulesFilename: c.GoModulesFilename,
+		MainModules:       c.mainModules,
 	}, nil
 
 }
@@ -54,6 +65,9 @@ type ModulesConfig struct {
 
 	// Set if this is a Go modules enabled project.
 	GoModulesFilename string
+
+	// The project itself, plus every hugo.work workspace member, if any.
+	MainModules *MainModules
 }
 
 type collected struct {
@@ -70,6 +84,12 @@ type collected struct {
 	// Ordered list of collected modules, including Go Modules and theme
 	// components stored below /themes.
 	modules Modules
+
+	// The project itself, plus every hugo.work workspace member, if any.
+	mainModules *MainModules
+
+	// Set if workspaceFilename pointed to a real hugo.work file.
+	workspace *Workspace
 }
 
 // Collects and creates a module tree.
@@ -87,8 +107,17 @@ type vendoredModule struct {
 
 func (c *collector) initModules() error {
 	c.collected = &collected{
-		seen:     make(map[string]bool),
-		vendored: make(map[string]vendoredModule),
+		seen:        make(map[string]bool),
+		vendored:    make(map[string]vendoredModule),
+		mainModules: newMainModules(),
+	}
+
+	if c.workspaceFilename != "" {
+		ws, err := LoadWorkspace(c.fs, c.workspaceFilename)
+		if err != nil {
+			return err
+		}
+		c.workspace = ws
 	}
 
 	// We may fail later if we don't find the mods.
@@ -111,21 +140,47 @@ func (c *collector) getVendoredDir(path string) (vendoredModule, bool) {
 	return v, found
 }
 
+// getReplacement looks up path among the project's top-level
+// Config.Replacements. Only the project's own Replacements are consulted,
+// never a theme's, mirroring how a go.mod "replace" directive only takes
+// effect in the main module.
+func (c *collector) getReplacement(path string) (Replace, bool) {
+	for _, r := range c.moduleConfig.Replacements {
+		if r.Old == path {
+			return r, true
+		}
+	}
+	return Replace{}, false
+}
+
 // TODO(bep) mod
 const zeroVersion = ""
 
 func (c *collector) add(owner Module, moduleImport Import) (Module, error) {
 	var (
-		mod       *goModule
-		moduleDir string
-		version   string
-		vendored  bool
+		mod        *goModule
+		moduleDir  string
+		version    string
+		vendored   bool
+		isReplaced bool
 	)
 
 	modulePath := moduleImport.Path
 	realOwner := owner
 
-	if !c.ignoreVendor {
+	if repl, found := c.getReplacement(modulePath); found {
+		dir := repl.New
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(c.workingDir, dir)
+		}
+		if found, _ := afero.Exists(c.fs, dir); !found {
+			return nil, c.wrapModuleNotFound(errors.Errorf("module replacement: directory %q for module %q not found", dir, modulePath))
+		}
+
+		moduleDir = dir
+		version = repl.Version
+		isReplaced = true
+	} else if !c.ignoreVendor {
 		if err := c.collectModulesTXT(owner); err != nil {
 			return nil, err
 		}
@@ -183,14 +238,26 @@ func (c *collector) add(owner Module, moduleImport Import) (Module, error) {
 		moduleDir += fileSeparator
 	}
 
+	var replaced Module
+	if isReplaced {
+		replaced = &moduleAdapter{
+			path:    modulePath,
+			dir:     moduleDir,
+			version: version,
+			fs:      c.fs,
+		}
+	}
+
 	ma := &moduleAdapter{
 		dir:       moduleDir,
 		vendor:    vendored,
 		gomod:     mod,
 		modImport: moduleImport,
 		version:   version,
+		fs:        c.fs,
 		// This may be the owner of the _vendor dir
-		owner: realOwner,
+		owner:   realOwner,
+		replace: replaced,
 	}
 	if mod == nil {
 		ma.path = modulePath
@@ -292,6 +359,16 @@ func (c *collector) collect() error {
 		gomod: gomod,
 	}
 
+	c.mainModules.add(projectMod)
+
+	if c.workspace != nil {
+		for _, dir := range c.workspace.Use {
+			if _, err := c.addWorkspaceMember(projectMod, dir); err != nil {
+				return err
+			}
+		}
+	}
+
 	if err := c.addAndRecurse(projectMod, c.moduleConfig); err != nil {
 		return err
 	}
@@ -299,6 +376,84 @@ func (c *collector) collect() error {
 	return nil
 }
 
+// addWorkspaceMember adds dir, a directory listed by a "use" directive in
+// the project's hugo.work file, directly to the module tree as an
+// already-resolved main module: its module path (read from its own go.mod,
+// falling back to its directory name) is marked as seen so that if owner's
+// own module config also imports it, that import is deduped against this
+// entry rather than triggering a "go get" or a _vendor lookup, and its own
+// module config, if any, is recursed into so its imports contribute to the
+// graph too.
+func (c *collector) addWorkspaceMember(owner Module, dir string) (Module, error) {
+	if found, _ := afero.Exists(c.fs, dir); !found {
+		return nil, errors.Errorf("hugo.work: workspace directory %q not found", dir)
+	}
+
+	path, err := c.readModulePath(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	// Seed seen so a matching import elsewhere in the tree resolves here
+	// instead of being re-fetched.
+	c.isSeen(path)
+
+	if !strings.HasSuffix(dir, fileSeparator) {
+		dir += fileSeparator
+	}
+
+	wm := &moduleAdapter{
+		path:      path,
+		dir:       dir,
+		owner:     owner,
+		workspace: true,
+		fs:        c.fs,
+	}
+
+	if err := wm.validateAndApplyDefaults(c.fs); err != nil {
+		return nil, err
+	}
+
+	if err := c.applyThemeConfig(wm); err != nil {
+		return nil, err
+	}
+
+	c.modules = append(c.modules, wm)
+	c.mainModules.add(wm)
+
+	if err := c.addThemeNamesFromTheme(wm); err != nil {
+		return nil, err
+	}
+
+	return wm, nil
+}
+
+// readModulePath returns the module path declared by dir's go.mod. If dir
+// has no go.mod, its directory name is used instead -- good enough to
+// dedupe against within this build, consistent with how the project itself
+// falls back to the literal path "project" when it has no go.mod either.
+func (c *collector) readModulePath(dir string) (string, error) {
+	filename := filepath.Join(dir, goModFilename)
+	f, err := c.fs.Open(filename)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return filepath.Base(filepath.Clean(dir)), nil
+		}
+		return "", err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "module ") {
+			return strings.TrimSpace(strings.TrimPrefix(line, "module")), nil
+		}
+	}
+
+	return "", errors.Errorf("%s: missing module directive", filename)
+}
+
 func (c *collector) collectModulesTXT(owner Module) error {
 	vendorDir := filepath.Join(owner.Dir(), vendord)
 	filename := filepath.Join(vendorDir, vendorModulesFilename)
@@ -320,6 +475,11 @@ func (c *collector) collectModulesTXT(owner Module) error {
 	for scanner.Scan() {
 		// # github.com/alecthomas/chroma v0.6.3
 		line := scanner.Text()
+		if strings.HasPrefix(strings.TrimSpace(line), "## ") {
+			// The recorded content hash for the module above, consumed by
+			// Client.Verify, not relevant when resolving vendored dirs.
+			continue
+		}
 		line = strings.Trim(line, "# ")
 		line = strings.TrimSpace(line)
 		parts := strings.Fields(line)
@@ -0,0 +1,76 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package modules
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCollectReplacement(t *testing.T) {
+	assert := require.New(t)
+	fs := afero.NewMemMapFs()
+
+	assert.NoError(afero.WriteFile(fs, filepath.Join("mytheme", "layouts", "index.html"), []byte("theme"), 0755))
+
+	modConfig := Config{
+		Imports: []Import{{Path: "github.com/bep/mycomponent"}},
+		Replacements: []Replace{
+			{Old: "github.com/bep/mycomponent", New: "../mytheme"},
+		},
+	}
+
+	client := NewClient(ClientConfig{
+		Fs:           fs,
+		WorkingDir:   "myproject",
+		ModuleConfig: modConfig,
+	})
+
+	mc, err := client.Collect()
+	assert.NoError(err)
+	assert.Len(mc.Modules, 1)
+
+	m := mc.Modules[0]
+	assert.Equal("github.com/bep/mycomponent", m.Path())
+	assert.Equal(filepath.Join("mytheme")+string(filepath.Separator), m.Dir())
+
+	var graphb bytes.Buffer
+	assert.NoError(client.Graph(&graphb))
+	assert.Contains(graphb.String(), "=> "+m.Dir())
+}
+
+func TestCollectReplacementNotFound(t *testing.T) {
+	assert := require.New(t)
+	fs := afero.NewMemMapFs()
+
+	modConfig := Config{
+		Imports: []Import{{Path: "github.com/bep/mycomponent"}},
+		Replacements: []Replace{
+			{Old: "github.com/bep/mycomponent", New: "../nope"},
+		},
+	}
+
+	client := NewClient(ClientConfig{
+		Fs:           fs,
+		WorkingDir:   "myproject",
+		ModuleConfig: modConfig,
+	})
+
+	_, err := client.Collect()
+	assert.Error(err)
+}
@@ -15,10 +15,12 @@ package modules
 
 import (
 	"sort"
+	"strings"
 
 	"github.com/gohugoio/hugo/config"
 	"github.com/mitchellh/mapstructure"
 	"github.com/pkg/errors"
+	"github.com/spf13/afero"
 )
 
 var (
@@ -45,6 +47,28 @@ func init() {
 
 type Config struct {
 	Imports []Import
+
+	// Local overrides of one or more Imports, resolved directly against a
+	// directory on disk instead of _vendor, Go Modules or themesDir. Only
+	// the project's own Replacements are used, and they apply to every
+	// matching import anywhere in the module tree; a theme's Replacements
+	// are ignored, mirroring how a go.mod "replace" directive only takes
+	// effect in the main module.
+	Replacements []Replace
+
+	// Module paths excluded from the hugo.sum integrity check performed
+	// during Collect, see Client.lockGoModules.
+	NoVerify []string
+}
+
+// Replace overrides the module at Old with the local directory at New,
+// consulted by collector.add before it would otherwise resolve Old via
+// _vendor, "go get" or themesDir. New is either absolute or relative to
+// the project's working directory.
+type Replace struct {
+	Old     string
+	New     string
+	Version string
 }
 
 type Import struct {
@@ -56,8 +80,55 @@ type Mount struct {
 	Source string // relative path in source repo, e.g. "scss"
 	Target string // relative target path, e.g. "assets/bootstrap/scss"
 
-	// TODO(bep) mod
+	// Lang ties this mount to a content language, e.g. "en". Only
+	// meaningful for mounts targeting a language-aware component
+	// (content, data, i18n); the files it contributes are attributed to
+	// that language throughout page/data assembly via the FileMeta
+	// surfaced on every decorated os.FileInfo, see LangProvider.
 	Lang string
+
+	// Disable, when set, makes Collect skip this mount entirely, as if it
+	// were never declared -- useful for conditionally turning off a
+	// mount from a module's own config without having to remove it.
+	Disable bool
+
+	// Glob patterns (doublestar syntax, e.g. "**/*.scss") matched against the
+	// mounted file's path relative to Source. Only used if non-empty.
+	Includes []string
+
+	// Glob patterns (doublestar syntax) matched against the mounted file's
+	// path relative to Source. Files matching any of these are hidden from
+	// the mounted filesystem, even if they also match Includes.
+	Excludes []string
+
+	// How long to cache a remote Source (see IsRemoteMountSource) before
+	// revalidating it with the origin server. A time.ParseDuration string,
+	// e.g. "10m"; defaults to defaultRemoteMountMaxAge when empty or
+	// unparsable. Not used for local mounts.
+	CacheDuration string
+
+	// Set by validateAndApplyDefaults once Source has been resolved to a
+	// real directory -- either a local one, or the local cache directory a
+	// remote Source was fetched into. A chroot rooted at that directory, so
+	// callers never need to re-join Source against the owning module's Dir().
+	fs afero.Fs
+}
+
+// Fs returns a filesystem rooted at this mount's resolved Source directory.
+// It is set for the mounts declared in config once the owning module has
+// been validated, which happens as part of Client.Collect. The default
+// mounts validateAndApplyDefaults creates for a module that declares none
+// have a nil Fs.
+func (m Mount) Fs() afero.Fs {
+	return m.fs
+}
+
+// IsRemoteMountSource reports whether source is a remote URL rather than a
+// path relative to the owning module's directory.
+//
+// TODO(bep) mod: support git and s3-style source URLs; only http(s) today.
+func IsRemoteMountSource(source string) bool {
+	return strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://")
 }
 
 // DecodeConfig creates a modules Config from a given Hugo configuration.
@@ -34,6 +34,11 @@ target="assets/bootstrap/scss"
 source="src/markdown/blog"
 target="content/blog"
 lang="en"
+includes=["**/*.md"]
+excludes=["**/_index.md"]
+[[module.replacements]]
+old="github.com/bep/mycomponent"
+new="../mycomponent"
 `
 	cfg, err := config.FromConfigString(tomlConfig, "toml")
 	assert.NoError(err)
@@ -47,6 +52,12 @@ lang="en"
 	assert.Equal("src/markdown/blog", imp.Mounts[1].Source)
 	assert.Equal("content/blog", imp.Mounts[1].Target)
 	assert.Equal("en", imp.Mounts[1].Lang)
+	assert.Equal([]string{"**/*.md"}, imp.Mounts[1].Includes)
+	assert.Equal([]string{"**/_index.md"}, imp.Mounts[1].Excludes)
+
+	assert.Len(mcfg.Replacements, 1)
+	assert.Equal("github.com/bep/mycomponent", mcfg.Replacements[0].Old)
+	assert.Equal("../mycomponent", mcfg.Replacements[0].New)
 
 }
 
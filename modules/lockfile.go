@@ -0,0 +1,189 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package modules
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/afero"
+)
+
+// hugoSumFilename is this package's own lockfile, written next to go.mod,
+// pinning the content hash of every resolved Go module. It uses the same
+// textual shape as Go's own go.sum (two "path version[/go.mod] h1:hash"
+// lines per module), but its hashes come from hashDir, this package's own
+// simplified H1 reimplementation, not go.sum's.
+//
+// Note that the real GOSUMDB transparency log -- a network service that
+// cross-checks a hash against every other build that has ever seen that
+// module version -- has no equivalent here; there is nothing to talk to
+// in this offline context. NoVerify is this package's substitute: an
+// explicit opt-out for modules a user has already vetted by some other
+// means, rather than an opt-in trust log.
+const hugoSumFilename = "hugo.sum"
+
+// lockRecord is one pinned module's hashes: hash is its content hash (see
+// hashDir), modHash is its go.mod file's hash. modHash may be empty if the
+// module has no go.mod of its own.
+type lockRecord struct {
+	hash    string
+	modHash string
+}
+
+// lockGoModules pins the content hash of every resolved, non-vendored Go
+// module in modules to hugo.sum: modules already recorded there are
+// verified against their pinned hash, modules seen for the first time are
+// added, mirroring how go.sum grows as new dependencies are resolved.
+// Modules listed in Config.NoVerify are skipped entirely. A no-op for
+// projects that are not Go Modules enabled.
+func (c *Client) lockGoModules(modules Modules) error {
+	if c.GoModulesFilename == "" {
+		return nil
+	}
+
+	noVerify := make(map[string]bool)
+	for _, p := range c.moduleConfig.NoVerify {
+		noVerify[p] = true
+	}
+
+	filename := filepath.Join(c.workingDir, hugoSumFilename)
+	locked, err := readLockfile(c.fs, filename)
+	if err != nil {
+		return err
+	}
+
+	var msgs []string
+	changed := false
+
+	for _, m := range modules {
+		if !m.IsGoMod() || m.Vendor() || noVerify[m.Path()] {
+			continue
+		}
+
+		key := m.Path() + "@" + m.Version()
+
+		hash, err := hashDir(c.fs, m.Dir())
+		if err != nil {
+			return errors.Wrapf(err, "failed to hash module %q", m.Path())
+		}
+
+		var modHash string
+		if b, err := afero.ReadFile(c.fs, filepath.Join(m.Dir(), goModFilename)); err == nil {
+			sum := sha256.Sum256(b)
+			modHash = "h1:" + base64.StdEncoding.EncodeToString(sum[:])
+		}
+
+		rec, found := locked[key]
+		if !found {
+			locked[key] = lockRecord{hash: hash, modHash: modHash}
+			changed = true
+			continue
+		}
+
+		if rec.hash != hash {
+			msgs = append(msgs, fmt.Sprintf("%s: hugo.sum records %s, but the resolved module now hashes to %s", key, rec.hash, hash))
+			continue
+		}
+
+		if rec.modHash != "" && modHash != "" && rec.modHash != modHash {
+			msgs = append(msgs, fmt.Sprintf("%s/go.mod: hugo.sum records %s, but go.mod now hashes to %s", key, rec.modHash, modHash))
+		}
+	}
+
+	if len(msgs) > 0 {
+		sort.Strings(msgs)
+		return errors.Errorf("module integrity check failed:\n%s", strings.Join(msgs, "\n"))
+	}
+
+	if changed {
+		return writeLockfile(c.fs, filename, locked)
+	}
+
+	return nil
+}
+
+func readLockfile(fs afero.Fs, filename string) (map[string]lockRecord, error) {
+	records := make(map[string]lockRecord)
+
+	f, err := fs.Open(filename)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return records, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 3 {
+			continue
+		}
+		path, version, hash := fields[0], fields[1], fields[2]
+
+		if strings.HasSuffix(version, "/"+goModFilename) {
+			key := path + "@" + strings.TrimSuffix(version, "/"+goModFilename)
+			rec := records[key]
+			rec.modHash = hash
+			records[key] = rec
+			continue
+		}
+
+		key := path + "@" + version
+		rec := records[key]
+		rec.hash = hash
+		records[key] = rec
+	}
+
+	return records, scanner.Err()
+}
+
+func writeLockfile(fs afero.Fs, filename string, records map[string]lockRecord) error {
+	keys := make([]string, 0, len(records))
+	for k := range records {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var buf bytes.Buffer
+	for _, key := range keys {
+		path, version := splitModuleKey(key)
+		rec := records[key]
+		fmt.Fprintf(&buf, "%s %s %s\n", path, version, rec.hash)
+		if rec.modHash != "" {
+			fmt.Fprintf(&buf, "%s %s/%s %s\n", path, version, goModFilename, rec.modHash)
+		}
+	}
+
+	return afero.WriteFile(fs, filename, buf.Bytes(), 0666)
+}
+
+// splitModuleKey splits a "path@version" lockfile key back into its parts.
+func splitModuleKey(key string) (path, version string) {
+	i := strings.LastIndex(key, "@")
+	if i < 0 {
+		return key, ""
+	}
+	return key[:i], key[i+1:]
+}
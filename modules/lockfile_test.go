@@ -0,0 +1,127 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package modules
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+func newLockTestModule(fs afero.Fs, path, version, dir, content string) *moduleAdapter {
+	afero.WriteFile(fs, filepath.Join(dir, "file.txt"), []byte(content), 0755)
+	afero.WriteFile(fs, filepath.Join(dir, goModFilename), []byte("module "+path), 0755)
+	return &moduleAdapter{
+		path:    path,
+		version: version,
+		dir:     dir,
+		fs:      fs,
+		gomod:   &goModule{Path: path, Version: version, Dir: dir},
+	}
+}
+
+func TestLockGoModulesFirstRunRecords(t *testing.T) {
+	assert := require.New(t)
+	fs := afero.NewMemMapFs()
+
+	m := newLockTestModule(fs, "github.com/foo/bar", "v1.0.0", "modcache/bar", "v1")
+
+	client := NewClient(ClientConfig{
+		Fs:         fs,
+		WorkingDir: "myproject",
+	})
+	client.GoModulesFilename = filepath.Join("myproject", goModFilename)
+
+	assert.NoError(client.lockGoModules(Modules{m}))
+
+	locked, err := readLockfile(fs, filepath.Join("myproject", hugoSumFilename))
+	assert.NoError(err)
+	assert.Contains(locked, "github.com/foo/bar@v1.0.0")
+}
+
+func TestLockGoModulesDetectsDrift(t *testing.T) {
+	assert := require.New(t)
+	fs := afero.NewMemMapFs()
+
+	m := newLockTestModule(fs, "github.com/foo/bar", "v1.0.0", "modcache/bar", "v1")
+
+	client := NewClient(ClientConfig{
+		Fs:         fs,
+		WorkingDir: "myproject",
+	})
+	client.GoModulesFilename = filepath.Join("myproject", goModFilename)
+
+	assert.NoError(client.lockGoModules(Modules{m}))
+
+	// Tamper with the resolved module's content after it has been pinned.
+	assert.NoError(afero.WriteFile(fs, filepath.Join("modcache/bar", "file.txt"), []byte("tampered"), 0755))
+
+	err := client.lockGoModules(Modules{m})
+	assert.Error(err)
+	assert.Contains(err.Error(), "github.com/foo/bar@v1.0.0")
+}
+
+// The .hugo_cache_complete marker materializeCache writes into a cached
+// module's directory is Hugo-internal bookkeeping, not part of the
+// module's real content, so it must not affect the pinned hash, and a
+// later rewrite of that marker alone (e.g. a cache refresh) must not be
+// reported as drift.
+func TestLockGoModulesIgnoresCacheMarker(t *testing.T) {
+	assert := require.New(t)
+	fs := afero.NewMemMapFs()
+
+	m := newLockTestModule(fs, "github.com/foo/bar", "v1.0.0", "modcache/bar", "v1")
+	assert.NoError(afero.WriteFile(fs, filepath.Join("modcache/bar", ".hugo_cache_complete"), []byte("v1.0.0"), 0666))
+
+	client := NewClient(ClientConfig{
+		Fs:         fs,
+		WorkingDir: "myproject",
+	})
+	client.GoModulesFilename = filepath.Join("myproject", goModFilename)
+
+	assert.NoError(client.lockGoModules(Modules{m}))
+
+	// Rewrite the marker, as a cache refresh would, without touching any
+	// real module file.
+	assert.NoError(afero.WriteFile(fs, filepath.Join("modcache/bar", ".hugo_cache_complete"), []byte("v1.0.1"), 0666))
+
+	assert.NoError(client.lockGoModules(Modules{m}))
+}
+
+func TestLockGoModulesNoVerify(t *testing.T) {
+	assert := require.New(t)
+	fs := afero.NewMemMapFs()
+
+	m := newLockTestModule(fs, "github.com/foo/bar", "v1.0.0", "modcache/bar", "v1")
+
+	client := NewClient(ClientConfig{
+		Fs:           fs,
+		WorkingDir:   "myproject",
+		ModuleConfig: Config{NoVerify: []string{"github.com/foo/bar"}},
+	})
+	client.GoModulesFilename = filepath.Join("myproject", goModFilename)
+
+	assert.NoError(client.lockGoModules(Modules{m}))
+
+	assert.NoError(afero.WriteFile(fs, filepath.Join("modcache/bar", "file.txt"), []byte("tampered"), 0755))
+
+	// Still fine -- the module is excluded from hugo.sum entirely.
+	assert.NoError(client.lockGoModules(Modules{m}))
+
+	locked, err := readLockfile(fs, filepath.Join("myproject", hugoSumFilename))
+	assert.NoError(err)
+	assert.NotContains(locked, "github.com/foo/bar@v1.0.0")
+}
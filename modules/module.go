@@ -17,15 +17,25 @@
 package modules
 
 import (
+	"net/http"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
+	"github.com/gohugoio/hugo/common/hugio"
 	"github.com/gohugoio/hugo/config"
+	"github.com/gohugoio/hugo/hugofs"
+	"github.com/gohugoio/hugo/hugofs/glob"
 	"github.com/pkg/errors"
 	"github.com/spf13/afero"
 )
 
+// defaultRemoteMountMaxAge is how long a remote (e.g. http(s)) Mount.Source
+// is cached before being revalidated with the origin server, see
+// Mount.CacheDuration.
+const defaultRemoteMountMaxAge = 1 * time.Hour
+
 var _ Module = (*moduleAdapter)(nil)
 
 type Module interface {
@@ -76,11 +86,27 @@ type moduleAdapter struct {
 	owner     Module
 	modImport Import
 
+	// Set if this module was provided by a workspace (hugo.work) "use"
+	// directive rather than resolved as a regular import, see
+	// collector.addWorkspaceMember.
+	workspace bool
+
+	// Set if this module was resolved via a Config.Replacements entry
+	// instead of _vendor, Go Modules or themesDir, see collector.add.
+	replace Module
+
 	configFilename string
 	cfg            config.Provider
 
 	// Set if a Go module.
 	gomod *goModule
+
+	// The filesystem used to resolve and, for non-vendored Go modules,
+	// cache this module's files. Set by the collector.
+	fs afero.Fs
+
+	// Set once Dir has materialized the cache for a non-vendored Go module.
+	cacheDir string
 }
 
 func (m *moduleAdapter) Cfg() config.Provider {
@@ -96,7 +122,56 @@ func (m *moduleAdapter) Dir() string {
 	if !m.IsGoMod() || m.dir != "" {
 		return m.dir
 	}
-	return m.gomod.Dir
+
+	if m.cacheDir != "" {
+		return m.cacheDir
+	}
+
+	cacheDir, err := m.materializeCache()
+	if err != nil {
+		// Caching is an optimization, not a correctness requirement; fall
+		// back to reading straight from the Go module cache.
+		return m.gomod.Dir
+	}
+	m.cacheDir = cacheDir
+
+	return m.cacheDir
+}
+
+// moduleCacheMaxAge is how long a resolved, non-vendored Go module is kept
+// in the Hugo module filecache before it is considered stale. Since a given
+// modulePath@version is immutable by definition, this is generous.
+const moduleCacheMaxAge = 7 * 24 * time.Hour
+
+// materializeCache copies this module's files, once, into a stable cache
+// directory below hugofs.ModuleCacheDir so subsequent builds -- including
+// fully offline ones -- don't need to touch the Go module cache again.
+//
+// TODO(bep) mod: once Dir() callers work against an afero.Fs instead of a
+// bare string, swap this for hugofs.NewCacheOnReadFs directly and drop the
+// eager copy below.
+func (m *moduleAdapter) materializeCache() (string, error) {
+	fs := m.fs
+	if fs == nil {
+		fs = afero.NewOsFs()
+	}
+
+	_, dir, err := hugofs.NewModuleCacheFs(afero.NewBasePathFs(fs, m.gomod.Dir), m.Path(), m.Version(), moduleCacheMaxAge)
+	if err != nil {
+		return "", err
+	}
+
+	marker := filepath.Join(dir, ".hugo_cache_complete")
+	if fi, err := os.Stat(marker); err == nil && time.Since(fi.ModTime()) < moduleCacheMaxAge {
+		// Already cached and still fresh.
+		return dir, nil
+	}
+
+	if err := hugio.CopyDir(fs, m.gomod.Dir, dir, func(string) bool { return true }); err != nil {
+		return "", err
+	}
+
+	return dir, afero.WriteFile(afero.NewOsFs(), marker, []byte(m.Version()), 0666)
 }
 
 func (m *moduleAdapter) IsGoMod() bool {
@@ -108,6 +183,9 @@ func (m *moduleAdapter) Owner() Module {
 }
 
 func (m *moduleAdapter) Replace() Module {
+	if m.replace != nil {
+		return m.replace
+	}
 	if m.IsGoMod() && !m.Vendor() && m.gomod.Replace != nil {
 		return &moduleAdapter{
 			gomod: m.gomod.Replace,
@@ -144,21 +222,26 @@ func (m *moduleAdapter) validateAndApplyDefaults(fs afero.Fs) error {
 	baseErr := errors.Errorf("invalid module config for %q", m.Path())
 	dir := m.Dir()
 
+	// Disabled mounts are dropped up front, as if they were never declared,
+	// so they can't trigger validation errors or suppress the
+	// no-mounts-declared default below.
+	var enabled []Mount
 	for _, mnt := range m.modImport.Mounts {
+		if !mnt.Disable {
+			enabled = append(enabled, mnt)
+		}
+	}
+	m.modImport.Mounts = enabled
+
+	for i := range m.modImport.Mounts {
+		mnt := &m.modImport.Mounts[i]
+
 		if mnt.Source == "" || mnt.Target == "" {
 			return errors.Wrap(baseErr, "both source and target must be set")
 		}
 
-		mnt.Source = filepath.Clean(mnt.Source)
 		mnt.Target = filepath.Clean(mnt.Target)
 
-		// Verify that Source exists
-		sourceDir := filepath.Join(dir, mnt.Source)
-		_, err := fs.Stat(sourceDir)
-		if err != nil {
-			return errors.Wrapf(baseErr, "module mount source not found: %q", mnt.Source)
-		}
-
 		// Verify that target points to one of the predefined component dirs
 		targetBase := mnt.Target
 		idxPathSep := strings.Index(mnt.Target, string(os.PathSeparator))
@@ -168,6 +251,40 @@ func (m *moduleAdapter) validateAndApplyDefaults(fs afero.Fs) error {
 		if !componentFoldersSet[targetBase] {
 			return errors.Wrapf(baseErr, "mount target must be one of: %v", componentFolders)
 		}
+
+		// Verify that the include/exclude globs, if any, are valid.
+		if _, err := glob.NewFilenameFilter(mnt.Includes, mnt.Excludes); err != nil {
+			return errors.Wrapf(baseErr, "invalid mount filter for %q: %s", mnt.Source, err)
+		}
+
+		if IsRemoteMountSource(mnt.Source) {
+			maxAge := defaultRemoteMountMaxAge
+			if mnt.CacheDuration != "" {
+				if d, err := time.ParseDuration(mnt.CacheDuration); err == nil {
+					maxAge = d
+				}
+			}
+
+			cacheDir, _, err := hugofs.FetchRemote(http.DefaultClient, mnt.Source, maxAge)
+			if err != nil {
+				return errors.Wrapf(baseErr, "failed to fetch remote mount %q: %s", mnt.Source, err)
+			}
+
+			mnt.fs = hugofs.NewSecureBasePathFs(afero.NewOsFs(), cacheDir)
+			continue
+		}
+
+		mnt.Source = filepath.Clean(mnt.Source)
+
+		// Verify that Source exists
+		sourceDir := filepath.Join(dir, mnt.Source)
+		if _, err := fs.Stat(sourceDir); err != nil {
+			return errors.Wrapf(baseErr, "module mount source not found: %q", mnt.Source)
+		}
+
+		// A chroot rooted at sourceDir, protected against a mount source
+		// that symlinks outside of it.
+		mnt.fs = hugofs.NewSecureBasePathFs(fs, sourceDir)
 	}
 
 	if len(m.modImport.Mounts) == 0 {
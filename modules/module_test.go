@@ -0,0 +1,65 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package modules
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateAndApplyDefaultsDisabledMount(t *testing.T) {
+	assert := require.New(t)
+	fs := afero.NewMemMapFs()
+
+	assert.NoError(afero.WriteFile(fs, filepath.Join("mytheme", "scss", "main.scss"), []byte("a"), 0755))
+
+	ma := &moduleAdapter{
+		path: "github.com/bep/mytheme",
+		dir:  "mytheme",
+		modImport: Import{
+			Mounts: []Mount{
+				{Source: "scss", Target: "assets/scss"},
+				// Missing Source/Target would normally fail validation;
+				// Disable must drop it before that check ever runs.
+				{Disable: true},
+			},
+		},
+	}
+
+	assert.NoError(ma.validateAndApplyDefaults(fs))
+	assert.Len(ma.Mounts(), 1)
+	assert.Equal("assets/scss", ma.Mounts()[0].Target)
+}
+
+func TestValidateAndApplyDefaultsInvalidFilter(t *testing.T) {
+	assert := require.New(t)
+	fs := afero.NewMemMapFs()
+
+	assert.NoError(afero.WriteFile(fs, filepath.Join("mytheme", "scss", "main.scss"), []byte("a"), 0755))
+
+	ma := &moduleAdapter{
+		path: "github.com/bep/mytheme",
+		dir:  "mytheme",
+		modImport: Import{
+			Mounts: []Mount{
+				{Source: "scss", Target: "assets/scss", Includes: []string{"[invalid"}},
+			},
+		},
+	}
+
+	assert.Error(ma.validateAndApplyDefaults(fs))
+}
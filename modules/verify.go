@@ -0,0 +1,176 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package modules
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/afero"
+)
+
+// hugoCacheCompleteMarker is the marker file materializeCache writes into a
+// cached, non-vendored Go module's directory once the copy is complete.
+// It's Hugo-internal bookkeeping, not part of the module's real content, so
+// hashDir always excludes it.
+const hugoCacheCompleteMarker = ".hugo_cache_complete"
+
+// hashDir computes a Go Modules style "h1:" content hash of dir: a manifest
+// of one "<sha256-hex> <relpath>\n" line per file below dir, sorted
+// lexicographically by relpath, itself hashed with SHA-256 and
+// base64-encoded. This matches the shape of
+// golang.org/x/mod/sumdb/dirhash.HashDir with an empty prefix, reimplemented
+// here against afero.Fs since that package is not a dependency of this
+// project.
+func hashDir(fs afero.Fs, dir string) (string, error) {
+	dir = filepath.Clean(dir)
+
+	var relpaths []string
+	err := afero.Walk(fs, dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		if info.Name() == hugoCacheCompleteMarker {
+			return nil
+		}
+		rel := filepath.ToSlash(strings.TrimPrefix(path, dir+string(filepath.Separator)))
+		relpaths = append(relpaths, rel)
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	sort.Strings(relpaths)
+
+	var manifest strings.Builder
+	for _, rel := range relpaths {
+		b, err := afero.ReadFile(fs, filepath.Join(dir, filepath.FromSlash(rel)))
+		if err != nil {
+			return "", err
+		}
+
+		sum := sha256.Sum256(b)
+		fmt.Fprintf(&manifest, "%x %s\n", sum, rel)
+	}
+
+	sum := sha256.Sum256([]byte(manifest.String()))
+	return "h1:" + base64.StdEncoding.EncodeToString(sum[:]), nil
+}
+
+type vendorHashRecord struct {
+	version string
+	hash    string
+}
+
+// readVendorHashes reads the "# path version" / "## h1:hash" line pairs
+// written by Vendor() out of vendorDir's modules.txt.
+func (c *Client) readVendorHashes(vendorDir string) (map[string]vendorHashRecord, error) {
+	filename := filepath.Join(vendorDir, vendorModulesFilename)
+	f, err := c.fs.Open(filename)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	records := make(map[string]vendorHashRecord)
+	var lastPath string
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case strings.HasPrefix(line, "## "):
+			if lastPath == "" {
+				continue
+			}
+			rec := records[lastPath]
+			rec.hash = strings.TrimPrefix(line, "## ")
+			records[lastPath] = rec
+		case strings.HasPrefix(line, "# "):
+			fields := strings.Fields(strings.TrimPrefix(line, "# "))
+			if len(fields) != 2 {
+				return nil, errors.Errorf("invalid modules list: %q", filename)
+			}
+			lastPath = fields[0]
+			records[lastPath] = vendorHashRecord{version: fields[1]}
+		}
+	}
+
+	return records, scanner.Err()
+}
+
+// Verify recomputes the content hash of every vendored module below
+// _vendor and compares it against the "## h1:" line Vendor() recorded for
+// it in modules.txt. It returns a non-nil error, listing every mismatch it
+// finds, if a vendored module has drifted from its recorded hash --
+// whether through tampering or a stale _vendor dir.
+//
+// This deliberately does not cross-check go.sum: the hash written to
+// modules.txt is this package's own simplified reimplementation of the Go
+// Modules H1 algorithm (see hashDir), not byte-identical to
+// golang.org/x/mod/sumdb/dirhash's encoding that actually populates
+// go.sum, so comparing the two would false-positive on every vendored
+// module that also happens to be in go.sum.
+//
+// Non-vendored Go modules are pinned and checked separately: see
+// Client.lockGoModules, which Collect runs on every call against
+// hugo.sum.
+func (c *Client) Verify() error {
+	vendorDir := filepath.Join(c.workingDir, vendord)
+
+	recorded, err := c.readVendorHashes(vendorDir)
+	if err != nil {
+		return err
+	}
+
+	if len(recorded) == 0 {
+		return nil
+	}
+
+	var msgs []string
+
+	for path, rm := range recorded {
+		dir := filepath.Join(vendorDir, path)
+
+		got, err := hashDir(c.fs, dir)
+		if err != nil {
+			return errors.Wrapf(err, "failed to hash vendored module %q", path)
+		}
+
+		if rm.hash != "" && got != rm.hash {
+			msgs = append(msgs, fmt.Sprintf("%s: modules.txt records %s, but the vendored tree now hashes to %s", path, rm.hash, got))
+		}
+	}
+
+	if len(msgs) > 0 {
+		sort.Strings(msgs)
+		return errors.Errorf("module verification failed:\n%s", strings.Join(msgs, "\n"))
+	}
+
+	return nil
+}
@@ -0,0 +1,120 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package modules
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHashDir(t *testing.T) {
+	assert := require.New(t)
+	fs := afero.NewMemMapFs()
+
+	assert.NoError(afero.WriteFile(fs, filepath.Join("mymod", "a.txt"), []byte("hello"), 0755))
+	assert.NoError(afero.WriteFile(fs, filepath.Join("mymod", "sub", "b.txt"), []byte("world"), 0755))
+
+	h1, err := hashDir(fs, "mymod")
+	assert.NoError(err)
+	assert.True(strings.HasPrefix(h1, "h1:"))
+
+	h2, err := hashDir(fs, "mymod")
+	assert.NoError(err)
+	assert.Equal(h1, h2, "hashing the same tree twice should be stable")
+
+	assert.NoError(afero.WriteFile(fs, filepath.Join("mymod", "a.txt"), []byte("changed"), 0755))
+	h3, err := hashDir(fs, "mymod")
+	assert.NoError(err)
+	assert.NotEqual(h1, h3, "changing a file's content should change the hash")
+}
+
+func TestClientVerify(t *testing.T) {
+	assert := require.New(t)
+	fs := afero.NewMemMapFs()
+
+	modPath := "github.com/foo/bar"
+	vendorDir := filepath.Join("myproject", vendord)
+	modDir := filepath.Join(vendorDir, modPath)
+
+	assert.NoError(afero.WriteFile(fs, filepath.Join(modDir, "data", "d.txt"), []byte("v1"), 0755))
+
+	client := NewClient(ClientConfig{
+		Fs:         fs,
+		WorkingDir: "myproject",
+	})
+
+	hash, err := hashDir(fs, modDir)
+	assert.NoError(err)
+
+	modulesTXT := "# " + modPath + " v1.0.0\n## " + hash + "\n"
+	assert.NoError(afero.WriteFile(fs, filepath.Join(vendorDir, vendorModulesFilename), []byte(modulesTXT), 0666))
+
+	// Matches the recorded hash.
+	assert.NoError(client.Verify())
+
+	// Tamper with the vendored tree; Verify should now fail.
+	assert.NoError(afero.WriteFile(fs, filepath.Join(modDir, "data", "d.txt"), []byte("tampered"), 0755))
+	err = client.Verify()
+	assert.Error(err)
+	assert.Contains(err.Error(), modPath)
+}
+
+// A go.sum entry for a vendored module, using the real (and different)
+// dirhash encoding, must never cause Verify to fail -- it only checks the
+// modules.txt hash it wrote itself. See the doc comment on Verify.
+func TestClientVerifyIgnoresGoSum(t *testing.T) {
+	assert := require.New(t)
+	fs := afero.NewMemMapFs()
+
+	modPath := "github.com/foo/bar"
+	vendorDir := filepath.Join("myproject", vendord)
+	modDir := filepath.Join(vendorDir, modPath)
+
+	assert.NoError(afero.WriteFile(fs, filepath.Join(modDir, "data", "d.txt"), []byte("v1"), 0755))
+
+	client := NewClient(ClientConfig{
+		Fs:         fs,
+		WorkingDir: "myproject",
+	})
+	client.GoModulesFilename = filepath.Join("myproject", goModFilename)
+
+	hash, err := hashDir(fs, modDir)
+	assert.NoError(err)
+
+	modulesTXT := "# " + modPath + " v1.0.0\n## " + hash + "\n"
+	assert.NoError(afero.WriteFile(fs, filepath.Join(vendorDir, vendorModulesFilename), []byte(modulesTXT), 0666))
+
+	// A go.sum entry using a completely different (real dirhash-shaped)
+	// hash for the same module@version must not trip Verify.
+	goSum := modPath + " v1.0.0 h1:notTheSameEncodingAtAll=\n"
+	assert.NoError(afero.WriteFile(fs, filepath.Join("myproject", goSumFilename), []byte(goSum), 0666))
+
+	assert.NoError(client.Verify())
+}
+
+func TestClientVerifyNoVendorDir(t *testing.T) {
+	assert := require.New(t)
+	fs := afero.NewMemMapFs()
+
+	client := NewClient(ClientConfig{
+		Fs:         fs,
+		WorkingDir: "myproject",
+	})
+
+	assert.NoError(client.Verify())
+}
@@ -0,0 +1,114 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package modules
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/afero"
+)
+
+// Workspace is the set of local directories listed by "use" directives in a
+// hugo.work file, Hugo's analogue to Go's multi-module workspace support.
+// Each directory is a project or theme in its own right, developed
+// side-by-side with the one holding the hugo.work file, without either
+// having to add a replace directive to find the other.
+type Workspace struct {
+	// Filename is the hugo.work file this Workspace was loaded from.
+	Filename string
+
+	// Use is the set of directories listed by "use" directives. A relative
+	// directory is joined to the directory holding Filename, so it is
+	// absolute only when Filename is.
+	Use []string
+}
+
+// LoadWorkspace reads and parses the hugo.work file at filename. A missing
+// file is not an error; it yields a nil Workspace, meaning workspace mode is
+// off for this build.
+func LoadWorkspace(fs afero.Fs, filename string) (*Workspace, error) {
+	f, err := fs.Open(filename)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	ws := &Workspace{Filename: filename}
+	baseDir := filepath.Dir(filename)
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "//") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) != 2 || fields[0] != "use" {
+			return nil, errors.Errorf("%s: invalid workspace directive: %q", filename, line)
+		}
+
+		dir := fields[1]
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(baseDir, dir)
+		}
+		ws.Use = append(ws.Use, dir)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return ws, nil
+}
+
+// MainModules is the set of modules that take part in a build as main
+// modules: the project itself, plus, with a Workspace in use, every other
+// workspace member. A main module's own directory always wins over
+// whatever would otherwise be resolved from the module proxy or a _vendor
+// dir, see collector.addWorkspaceMember.
+type MainModules struct {
+	modules map[string]Module
+}
+
+func newMainModules() *MainModules {
+	return &MainModules{modules: make(map[string]Module)}
+}
+
+func (mm *MainModules) add(m Module) {
+	mm.modules[pathKey(m.Path())] = m
+}
+
+// Contains reports whether path identifies one of the main modules.
+func (mm *MainModules) Contains(path string) bool {
+	_, found := mm.modules[pathKey(path)]
+	return found
+}
+
+// Dir returns the on-disk directory of the main module at path, and whether
+// one was found.
+func (mm *MainModules) Dir(path string) (string, bool) {
+	m, found := mm.modules[pathKey(path)]
+	if !found {
+		return "", false
+	}
+	return m.Dir(), true
+}
@@ -0,0 +1,75 @@
+// Copyright 2019 The Hugo Authors. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package modules
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadWorkspace(t *testing.T) {
+	assert := require.New(t)
+	fs := afero.NewMemMapFs()
+
+	work := `
+// A comment.
+use ./mysite
+use ../mytheme
+`
+	assert.NoError(afero.WriteFile(fs, filepath.Join("myproject", "hugo.work"), []byte(work), 0755))
+
+	ws, err := LoadWorkspace(fs, filepath.Join("myproject", "hugo.work"))
+	assert.NoError(err)
+	assert.Equal([]string{
+		filepath.Join("myproject", "mysite"),
+		filepath.Join("mytheme"),
+	}, ws.Use)
+}
+
+func TestLoadWorkspaceMissing(t *testing.T) {
+	assert := require.New(t)
+	fs := afero.NewMemMapFs()
+
+	ws, err := LoadWorkspace(fs, filepath.Join("myproject", "hugo.work"))
+	assert.NoError(err)
+	assert.Nil(ws)
+}
+
+func TestLoadWorkspaceInvalid(t *testing.T) {
+	assert := require.New(t)
+	fs := afero.NewMemMapFs()
+
+	assert.NoError(afero.WriteFile(fs, "hugo.work", []byte("bogus line"), 0755))
+
+	_, err := LoadWorkspace(fs, "hugo.work")
+	assert.Error(err)
+}
+
+func TestMainModules(t *testing.T) {
+	assert := require.New(t)
+
+	mm := newMainModules()
+	mm.add(&moduleAdapter{path: "github.com/foo/site", dir: "/site/"})
+	mm.add(&moduleAdapter{path: "github.com/foo/theme", dir: "/theme/"})
+
+	assert.True(mm.Contains("github.com/foo/theme"))
+	assert.False(mm.Contains("github.com/foo/other"))
+
+	dir, found := mm.Dir("github.com/foo/theme")
+	assert.True(found)
+	assert.Equal("/theme/", dir)
+}